@@ -0,0 +1,87 @@
+package initializers
+
+import (
+	"context"
+
+	"github.com/rgraterol/beers-api/pkg/app"
+	"github.com/rgraterol/beers-api/pkg/metrics"
+)
+
+// NewApp builds the app of the environment config. Closing it waits for the rates snapshot in progress, stops
+// serving the metrics, closes the DB pool, exports the last spans and flushes the logs. When a step fails what was
+// opened is closed already.
+func NewApp() (a *app.App, err error) {
+	config, err := NewConfig()
+	if err != nil {
+		return nil, err
+	}
+	a = &app.App{Config: config, Metrics: &metrics.Registry{}}
+	defer func() {
+		if err != nil {
+			a.Close()
+			a = nil
+		}
+	}()
+
+	if a.Logger, err = NewLogger(config); err != nil {
+		return a, err
+	}
+	a.OnClose(func() error {
+		// Syncing stdout fails on terminals, only the log file matters
+		_ = a.Logger.Sync()
+		return nil
+	})
+
+	if a.Tracer, err = NewTracingProvider(config, a.Logger); err != nil {
+		return a, err
+	}
+	if a.Tracer != nil {
+		a.OnClose(closeTracing(a.Tracer))
+	}
+
+	if a.DB, err = NewDatabase(config, a.Metrics, a.Logger); err != nil {
+		return a, err
+	}
+	a.OnClose(closeDatabase(a.DB))
+	if a.Beers, err = NewBeerRepository(config, a.DB); err != nil {
+		return a, err
+	}
+
+	if a.AdminToken, err = NewAdminToken(config); err != nil {
+		return a, err
+	}
+	serverConfig, err := loadServerConfig(config)
+	if err != nil {
+		return a, err
+	}
+	a.ProblemDetails = serverConfig.ProblemDetails
+
+	a.HTTP = NewRestClient(a.Metrics)
+	currency, err := NewCurrency(config, a.DB, a.HTTP, a.Logger)
+	if err != nil {
+		return a, err
+	}
+	a.Rates = currency.Chain
+	a.RatesHistory = currency.Store
+	a.Rounding = currency.Rounding
+
+	if a.Liveness, a.Readiness, err = NewHealthCheckers(config, a.DB, currency.Cache); err != nil {
+		return a, err
+	}
+
+	metricsServer, err := NewMetricsServer(config, a.Logger, a.Metrics, currency.Cache)
+	if err != nil {
+		return a, err
+	}
+	if metricsServer != nil {
+		a.OnClose(closeMetrics(metricsServer))
+	}
+
+	if snapshotter := NewRatesSnapshotter(a.Context(context.Background()), currency); snapshotter != nil {
+		a.OnClose(func() error {
+			snapshotter.Stop()
+			return nil
+		})
+	}
+	return a, nil
+}
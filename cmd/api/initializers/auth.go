@@ -0,0 +1,28 @@
+package initializers
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/pkg/app"
+)
+
+// AuthConfiguration represents the access control configuration.
+type AuthConfiguration struct {
+	// AdminToken grants admin only operations such as purging beers, it can be overridden with ADMIN_TOKEN.
+	AdminToken string `yaml:"adminToken"`
+}
+
+// NewAdminToken reads the admin token of the auth config.
+func NewAdminToken(config *app.Config) (string, error) {
+	var authConfig AuthConfiguration
+	err := config.Section("auth", &authConfig)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the auth config")
+	}
+
+	if token := os.Getenv("ADMIN_TOKEN"); token != "" {
+		authConfig.AdminToken = token
+	}
+	return authConfig.AdminToken, nil
+}
@@ -0,0 +1,225 @@
+package initializers
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/pkg/app"
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/restclient"
+	"github.com/rgraterol/beers-api/pkg/usecases/currencylayer"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
+)
+
+const (
+	currencyLayerProvider = "currencylayer"
+	ecbProvider           = "ecb"
+	staticProvider        = "static"
+	databaseProvider      = "database"
+
+	defaultBreakerThreshold   = 5
+	defaultBreakerOpenTimeout = 30
+	// defaultECBCacheTTL is an hour, the ECB publishes its rates once a day
+	defaultECBCacheTTL = 3600
+
+	accessKeyEnv = "CURRENCYLAYER_ACCESS_KEY"
+)
+
+// CurrencyConfiguration represents the exchange rates configuration.
+type CurrencyConfiguration struct {
+	// Provider of the exchange rates, can be currencylayer, ecb or static.
+	Provider string `yaml:"provider"`
+	// URL is the currencylayer API address, defaults to api.currencylayer.com.
+	URL string `yaml:"url"`
+	// AccessKey of the currencylayer API, it can be overridden with CURRENCYLAYER_ACCESS_KEY or AccessKeyFile.
+	AccessKey string `yaml:"accessKey"`
+	// AccessKeyFile is a secrets file holding the currencylayer access key, relative to the app dir unless absolute.
+	AccessKeyFile string `yaml:"accessKeyFile"`
+	// HTTPS calls currencylayer through TLS, only available on its paid plans.
+	HTTPS bool `yaml:"https"`
+	// Timeout is the time in seconds a currencylayer or ECB request can take.
+	Timeout int `yaml:"timeout"`
+	// ECBURL overrides the address of the ECB daily rates.
+	ECBURL string `yaml:"ecbUrl"`
+	// RatesFile is the YAML file served by the static provider, relative to the app dir unless absolute.
+	RatesFile string `yaml:"ratesFile"`
+	// CacheTTL is the time in seconds the currencylayer quotes or the ECB rates are fresh.
+	CacheTTL int `yaml:"cacheTTL"`
+	// StaleGrace is the time in seconds expired rates keep being served while they are refreshed in background.
+	StaleGrace int `yaml:"staleGrace"`
+	// Fallbacks are tried in order when the provider fails, can be database (last known good rates) or static.
+	Fallbacks []string `yaml:"fallbacks"`
+	// Breaker configures the circuit breaker around the provider.
+	Breaker BreakerConfiguration `yaml:"breaker"`
+	// Rounding of converted prices to the currency minor units: half_up, half_even, up, down, ceiling or floor.
+	Rounding string `yaml:"rounding"`
+	// SnapshotInterval is the time in seconds between the snapshots of the provider rates, zero disables them.
+	SnapshotInterval int `yaml:"snapshotInterval"`
+}
+
+// BreakerConfiguration represents a circuit breaker configuration.
+type BreakerConfiguration struct {
+	// FailureThreshold is the amount of consecutive failures that opens the circuit.
+	FailureThreshold int `yaml:"failureThreshold"`
+	// OpenTimeout is the time in seconds the circuit stays open before trying the provider again.
+	OpenTimeout int `yaml:"openTimeout"`
+}
+
+// Currency is the exchange rates setup of the currency config.
+type Currency struct {
+	Config CurrencyConfiguration
+	// Chain is the configured provider behind its circuit breaker, followed by its fallbacks.
+	Chain *rates.Chain
+	// Store keeps the rates history, shared by the database fallback and the snapshots job.
+	Store *rates.DBStore
+	// Cache keeps the rates of the provider, nil for the static one which never refreshes them.
+	Cache rates.StatsInterface
+	// Rounding is the mode of the prices converted with the rates.
+	Rounding money.RoundingMode
+
+	logger *zap.SugaredLogger
+}
+
+// NewCurrency builds the exchange rates provider chain, its upstream providers are called through client.
+func NewCurrency(config *app.Config, g *gorm.DB, client *restclient.Client, logger *zap.Logger) (*Currency, error) {
+	c := Currency{Store: rates.NewDBStore(g), logger: logger.Sugar()}
+	err := config.Section("currency", &c.Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the currency config")
+	}
+
+	if c.Rounding, err = money.ParseRoundingMode(c.Config.Rounding); err != nil {
+		return nil, err
+	}
+
+	if err = c.newRatesChain(client); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// NewRatesSnapshotter starts the job saving the primary provider rates on the exchange_rates table, it is nil
+// when the snapshots are disabled. The snapshots log and trace with the values of ctx.
+func NewRatesSnapshotter(ctx context.Context, c *Currency) *rates.Snapshotter {
+	if c.Config.SnapshotInterval <= 0 {
+		c.logger.Info("exchange rates snapshots are disabled")
+		return nil
+	}
+	interval := time.Duration(c.Config.SnapshotInterval) * time.Second
+	snapshotter := rates.NewSnapshotter(c.Chain.Providers[0], c.Store, interval)
+	snapshotter.Start(ctx)
+	c.logger.Info("exchange rates snapshots taken every ", interval)
+	return snapshotter
+}
+
+// newRatesChain puts the configured provider behind a circuit breaker, followed by its fallbacks.
+func (c *Currency) newRatesChain(client *restclient.Client) error {
+	config := &c.Config
+	primary, err := c.newRatesProvider(client)
+	if err != nil {
+		return err
+	}
+	threshold, openTimeout := config.Breaker.FailureThreshold, config.Breaker.OpenTimeout
+	if threshold <= 0 {
+		threshold = defaultBreakerThreshold
+	}
+	if openTimeout <= 0 {
+		openTimeout = defaultBreakerOpenTimeout
+	}
+	c.Chain = &rates.Chain{
+		Providers: []rates.Interface{rates.NewBreaker(primary, threshold, time.Duration(openTimeout) * time.Second)},
+	}
+	for _, fallback := range config.Fallbacks {
+		switch fallback {
+		case databaseProvider:
+			c.Chain.Providers = append(c.Chain.Providers, c.Store)
+			c.Chain.Recorder = c.Store
+		case staticProvider:
+			static, err := rates.NewStaticProvider(staticRatesPath(config))
+			if err != nil {
+				return err
+			}
+			c.Chain.Providers = append(c.Chain.Providers, static)
+		default:
+			return errors.New("unknown rates fallback " + fallback)
+		}
+	}
+	return nil
+}
+
+func newCurrencyLayer(config *CurrencyConfiguration, client *restclient.Client) (*currencylayer.ProductiveLayer, error) {
+	accessKey, err := currencyAccessKey(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build the currencylayer client")
+	}
+	return currencylayer.NewProductiveLayer(currencylayer.Config{
+		Client:     client,
+		URL:        config.URL,
+		AccessKey:  accessKey,
+		HTTPS:      config.HTTPS,
+		Timeout:    time.Duration(config.Timeout) * time.Second,
+		CacheTTL:   time.Duration(config.CacheTTL) * time.Second,
+		StaleGrace: time.Duration(config.StaleGrace) * time.Second,
+	}), nil
+}
+
+// currencyAccessKey prefers the env var, then the secrets file and lastly the config value.
+func currencyAccessKey(config *CurrencyConfiguration) (string, error) {
+	if key := os.Getenv(accessKeyEnv); key != "" {
+		return key, nil
+	}
+	if config.AccessKeyFile != "" {
+		key, err := ioutil.ReadFile(appPath(config.AccessKeyFile))
+		if err != nil {
+			return "", errors.Wrap(err, "cannot read the currencylayer access key file")
+		}
+		return strings.TrimSpace(string(key)), nil
+	}
+	return config.AccessKey, nil
+}
+
+func (c *Currency) newRatesProvider(client *restclient.Client) (rates.Interface, error) {
+	config := &c.Config
+	switch config.Provider {
+	case currencyLayerProvider, "":
+		layer, err := newCurrencyLayer(config, client)
+		if err != nil {
+			return nil, err
+		}
+		c.Cache = layer
+		return layer, nil
+	case ecbProvider:
+		ttl := config.CacheTTL
+		if ttl <= 0 {
+			ttl = defaultECBCacheTTL
+		}
+		timeout := time.Duration(config.Timeout) * time.Second
+		ecb := &rates.ECBProvider{URL: config.ECBURL, Client: client, Timeout: timeout}
+		cache := rates.NewCache(ecb, time.Duration(ttl) * time.Second, time.Duration(config.StaleGrace) * time.Second, timeout)
+		c.Cache = cache
+		return cache, nil
+	case staticProvider:
+		c.logger.Info("the static rates never refresh, they have no cache to check")
+		return rates.NewStaticProvider(staticRatesPath(config))
+	}
+	return nil, errors.New("unknown rates provider " + config.Provider)
+}
+
+func staticRatesPath(config *CurrencyConfiguration) string {
+	return appPath(config.RatesFile)
+}
+
+// appPath resolves path against the app dir unless it is absolute.
+func appPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(appDir(), path)
+}
@@ -0,0 +1,48 @@
+package initializers
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrencyAccessKey(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "access_key")
+	assert.Nil(t, ioutil.WriteFile(keyFile, []byte("  file-key\n"), 0600))
+	missingFile := filepath.Join(dir, "missing")
+
+	cases := []struct {
+		name     string
+		env      string
+		config   CurrencyConfiguration
+		expected string
+		err      string
+	}{
+		{name: "env over file and config", env: "env-key", config: CurrencyConfiguration{AccessKey: "config-key", AccessKeyFile: keyFile}, expected: "env-key"},
+		{name: "env over a missing file", env: "env-key", config: CurrencyConfiguration{AccessKeyFile: missingFile}, expected: "env-key"},
+		{name: "file over config, trimmed", config: CurrencyConfiguration{AccessKey: "config-key", AccessKeyFile: keyFile}, expected: "file-key"},
+		{name: "config alone", config: CurrencyConfiguration{AccessKey: "config-key"}, expected: "config-key"},
+		{name: "missing file never falls back to config", config: CurrencyConfiguration{AccessKey: "config-key", AccessKeyFile: missingFile}, err: "cannot read the currencylayer access key file"},
+		{name: "unreadable file never falls back to config", config: CurrencyConfiguration{AccessKey: "config-key", AccessKeyFile: dir}, err: "cannot read the currencylayer access key file"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Given
+			t.Setenv(accessKeyEnv, c.env)
+			// When
+			key, err := currencyAccessKey(&c.config)
+			// Then
+			if c.err != "" {
+				assert.NotNil(t, err)
+				assert.Contains(t, err.Error(), c.err)
+				assert.Empty(t, key)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, c.expected, key)
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package initializers
 
 import (
+	"context"
+	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -10,12 +12,15 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/db/migrations"
+	"github.com/rgraterol/beers-api/pkg/app"
 	"github.com/rgraterol/beers-api/pkg/db"
+	"github.com/rgraterol/beers-api/pkg/metrics"
+	"github.com/rgraterol/beers-api/pkg/tracing"
 	"github.com/rgraterol/beers-api/pkg/usecases/beers"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
 )
 
-var DatabaseConfig DatabaseConfiguration
-
 // DatabaseConfiguration represents a database configuration.
 type DatabaseConfiguration struct {
 	// URL is the database address.
@@ -26,61 +31,112 @@ type DatabaseConfiguration struct {
 	MaxOpenConns int `yaml:"maxOpenConns"`
 	// ConnMaxLifetime sets the maximum amount of time in minutes a connection may be reused.
 	ConnMaxLifetime int `yaml:"connMaxLifetime"`
-	// Automigrate set condition to automatically migrate db schema.
-	AutoMigrate bool `yaml:"autoMigrate"`
+	// Migrate applies the pending migrations on startup, the pods starting together take turns.
+	Migrate bool `yaml:"migrate"`
+	// Demo runs without MySQL, the beers are kept in memory and the rest on an in-memory SQLite DB.
+	Demo bool `yaml:"demo"`
 }
 
-func DatabaseInitializer() {
-	err := LoadConfigSection("database", &DatabaseConfig)
+func loadDatabaseConfig(config *app.Config) (*DatabaseConfiguration, error) {
+	var databaseConfig DatabaseConfiguration
+	err := config.Section("database", &databaseConfig)
 	if err != nil {
-		panic(errors.Wrap(err, "failed to read the database config"))
+		return nil, errors.Wrap(err, "failed to read the database config")
 	}
-
 	if url := os.Getenv("DATABASE_URL"); url != "" {
-		DatabaseConfig.URL = url
+		databaseConfig.URL = url
 	}
+	return &databaseConfig, nil
+}
 
-	db.Gorm, err = gorm.Open(mysql.Open(DatabaseConfig.URL), &gorm.Config{Logger: initGormLogger()})
+// NewDatabase opens the MySQL connection pool, measured on registry, and applies the pending migrations when
+// configured, logging them on logger. On the demo mode it opens the mock DB instead.
+func NewDatabase(config *app.Config, registry *metrics.Registry, logger *zap.Logger) (*gorm.DB, error) {
+	databaseConfig, err := loadDatabaseConfig(config)
 	if err != nil {
-		panic(errors.Wrap(err, "failed to initialize the DB"))
+		return nil, err
 	}
-	pool, err := db.Gorm.DB()
+	if databaseConfig.Demo {
+		return NewMockDatabase()
+	}
+	g, err := openDatabase(databaseConfig, registry)
 	if err != nil {
-		panic(errors.Wrap(err, "failed to configure connection pool"))
+		return nil, err
+	}
+	if databaseConfig.Migrate {
+		ctx := tracing.ContextWithLogger(context.Background(), logger)
+		if err = db.NewMigrator(g, migrations.FS).Up(ctx); err != nil {
+			closeDatabase(g)()
+			return nil, errors.Wrap(err, "failed to migrate the DB")
+		}
 	}
-	pool.SetMaxIdleConns(DatabaseConfig.MaxIdleConns)
-	pool.SetMaxOpenConns(DatabaseConfig.MaxOpenConns)
-	pool.SetConnMaxLifetime(time.Duration(DatabaseConfig.ConnMaxLifetime))
+	return g, nil
+}
 
-	if DatabaseConfig.AutoMigrate {
-		err = runBeersMigration()
+// openDatabase opens the MySQL connection pool, instrumented with the metrics of registry and spans.
+func openDatabase(databaseConfig *DatabaseConfiguration, registry *metrics.Registry) (g *gorm.DB, err error) {
+	g, err = gorm.Open(mysql.Open(databaseConfig.URL), &gorm.Config{Logger: initGormLogger()})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize the DB")
+	}
+	defer func() {
 		if err != nil {
-			panic(err)
+			closeDatabase(g)()
 		}
+	}()
+	if err = g.Use(metrics.GormPlugin{Registry: registry}); err != nil {
+		return nil, errors.Wrap(err, "failed to instrument the DB")
 	}
+	if err = g.Use(tracing.GormPlugin{}); err != nil {
+		return nil, errors.Wrap(err, "failed to trace the DB")
+	}
+	pool, err := g.DB()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to configure connection pool")
+	}
+	pool.SetMaxIdleConns(databaseConfig.MaxIdleConns)
+	pool.SetMaxOpenConns(databaseConfig.MaxOpenConns)
+	pool.SetConnMaxLifetime(time.Duration(databaseConfig.ConnMaxLifetime))
+	return g, nil
 }
 
-func MockDatabaseInitializer() {
-	var err error
-	db.Gorm, err = gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{Logger: initGormLogger()})
+// NewBeerRepository keeps the beers on g, or in memory on the demo mode.
+func NewBeerRepository(config *app.Config, g *gorm.DB) (beers.BeerRepository, error) {
+	databaseConfig, err := loadDatabaseConfig(config)
 	if err != nil {
-		panic(errors.Wrap(err, "failed to connect gorm with mock DB"))
+		return nil, err
+	}
+	if databaseConfig.Demo {
+		return beers.NewMemoryRepository(), nil
 	}
-	err = runBeersMigration()
+	return beers.NewDBRepository(g), nil
+}
+
+// NewMockDatabase opens an in-memory SQLite DB for tests. The migrations are written for MySQL, so its tables are
+// created from the models.
+func NewMockDatabase() (*gorm.DB, error) {
+	g, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{Logger: initGormLogger()})
 	if err != nil {
-		panic(err)
+		return nil, errors.Wrap(err, "failed to connect gorm with mock DB")
+	}
+	if err = g.AutoMigrate(&beers.Beer{}, &rates.ExchangeRate{}); err != nil {
+		return nil, errors.Wrap(err, "cannot create the mock DB tables")
 	}
+	return g, nil
 }
 
-func runBeersMigration() error {
-	if db.Gorm.Migrator().HasTable(&beers.Beer{}) {
+// closeDatabase closes the connection pool of g.
+func closeDatabase(g *gorm.DB) func() error {
+	return func() error {
+		pool, err := g.DB()
+		if err == nil {
+			err = pool.Close()
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to close the DB pool")
+		}
 		return nil
 	}
-	err := db.Gorm.AutoMigrate(&beers.Beer{})
-	if err != nil {
-		return errors.Wrap(err,  "cannot run beers migration")
-	}
-	return nil
 }
 
 func initGormLogger() logger.Interface {
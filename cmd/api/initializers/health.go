@@ -0,0 +1,105 @@
+package initializers
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/db/migrations"
+	"github.com/rgraterol/beers-api/pkg/app"
+	"github.com/rgraterol/beers-api/pkg/db"
+	"github.com/rgraterol/beers-api/pkg/health"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
+)
+
+const (
+	defaultHealthTimeout = 2
+	defaultMaxRatesAge   = 7200
+)
+
+// HealthConfiguration represents the health checks configuration.
+type HealthConfiguration struct {
+	// Timeout is the time in seconds each check can take.
+	Timeout int `yaml:"timeout"`
+	// MaxRatesAge is the time in seconds the cached rates of the provider can go without a refresh before degrading
+	// readiness, it should be longer than the snapshots interval.
+	MaxRatesAge int `yaml:"maxRatesAge"`
+}
+
+// NewHealthCheckers answers the liveness and readiness checkers, the readiness one checks g and the rates of cache
+// when it is not nil.
+func NewHealthCheckers(config *app.Config, g *gorm.DB, cache rates.StatsInterface) (*health.Checker, *health.Checker, error) {
+	var healthConfig HealthConfiguration
+	err := config.Section("health", &healthConfig)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to read the health config")
+	}
+	databaseConfig, err := loadDatabaseConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	timeout, maxRatesAge := healthConfig.Timeout, healthConfig.MaxRatesAge
+	if timeout <= 0 {
+		timeout = defaultHealthTimeout
+	}
+	if maxRatesAge <= 0 {
+		maxRatesAge = defaultMaxRatesAge
+	}
+	liveness := &health.Checker{Timeout: seconds(timeout)}
+	readiness := &health.Checker{Timeout: seconds(timeout)}
+
+	readiness.Register(health.Check{Name: "database", Critical: true, Run: pingDatabase(g)})
+	if cache != nil {
+		// The rates fallbacks keep the box prices working, stale rates only degrade the app
+		readiness.Register(health.Check{Name: "rates_cache", Run: ratesCacheAge(cache, seconds(maxRatesAge))})
+	}
+	// The demo schema is created from the models and has no version. Without migrate the migrations are a deploy
+	// step of their own, a pending one only degrades the app so it keeps serving until they are applied.
+	if !databaseConfig.Demo {
+		readiness.Register(health.Check{Name: "migrations", Critical: databaseConfig.Migrate, Run: pendingMigrations(g)})
+	}
+	return liveness, readiness, nil
+}
+
+func pingDatabase(g *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		pool, err := g.DB()
+		if err != nil {
+			return err
+		}
+		return pool.PingContext(ctx)
+	}
+}
+
+func ratesCacheAge(cache rates.StatsInterface, maxAge time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		// An empty cache is filled by the first request
+		stats := cache.Stats()
+		if stats.Age > maxAge {
+			return fmt.Errorf("the rates are %s old, more than %s", stats.Age.Round(time.Second), maxAge)
+		}
+		return nil
+	}
+}
+
+func pendingMigrations(g *gorm.DB) func(ctx context.Context) error {
+	migrator := db.NewMigrator(g, migrations.FS)
+	return func(ctx context.Context) error {
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if status.Dirty {
+			return fmt.Errorf("the migration %d failed halfway", status.Version)
+		}
+		if status.Version == 0 && len(status.Pending) > 0 {
+			return errors.New("the schema has no version, apply the migrations or force the version of a DB created without them")
+		}
+		if len(status.Pending) > 0 {
+			return fmt.Errorf("the schema is at version %d, the migrations up to %d are pending", status.Version, status.Latest)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,55 @@
+package initializers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/app"
+	"github.com/rgraterol/beers-api/pkg/health"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadinessChecksTheCacheOfAnyProvider(t *testing.T) {
+	// Given
+	config, err := app.NewConfig("test", []byte("database:\n  demo: true\nhealth:\n  maxRatesAge: 60\n"))
+	assert.Nil(t, err)
+	g, err := NewMockDatabase()
+	assert.Nil(t, err)
+	ecb := rates.ProviderFunc(func(context.Context) (*rates.Rates, error) {
+		return &rates.Rates{Base: "EUR", Source: "ecb", Rates: map[string]money.Decimal{"USD": money.RequireFromString("1.1448")}}, nil
+	})
+	cache := rates.NewCache(ecb, time.Hour, 0, 0)
+	_, err = cache.GetRates(context.Background())
+	assert.Nil(t, err)
+	// When
+	_, readiness, err := NewHealthCheckers(config, g, cache)
+	// Then
+	assert.Nil(t, err)
+	report := readiness.Run(context.Background())
+	assert.Equal(t, health.StatusUp, report.Status)
+	var names []string
+	for _, check := range report.Checks {
+		names = append(names, check.Name)
+	}
+	assert.Contains(t, names, "rates_cache")
+}
+
+func TestRatesCacheAgeTooOld(t *testing.T) {
+	// Given
+	cache := rates.NewCache(rates.ProviderFunc(func(context.Context) (*rates.Rates, error) {
+		return &rates.Rates{Base: "EUR", Source: "ecb"}, nil
+	}), time.Hour, 0, 0)
+	check := ratesCacheAge(cache, time.Millisecond)
+	assert.Nil(t, check(context.Background()))
+	_, err := cache.GetRates(context.Background())
+	assert.Nil(t, err)
+	time.Sleep(5 * time.Millisecond)
+	// When
+	err = check(context.Background())
+	// Then
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "the rates are")
+}
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rgraterol/beers-api/pkg/app"
+	"github.com/rgraterol/beers-api/pkg/tracing"
 )
 
 // LoggerConfiguration represents configuration for logs.
@@ -16,10 +18,6 @@ type LoggerConfiguration struct {
 	Level string `yaml:"level"`
 }
 
-var loggerConfig LoggerConfiguration
-
-var logg *zap.Logger
-
 var levelMap = map[string]zapcore.Level{
 	"debug":  zapcore.DebugLevel,
 	"info":   zapcore.InfoLevel,
@@ -37,18 +35,18 @@ func createDirectoryIfDoesntExist() {
 	}
 }
 
-func getLogWriter() zapcore.WriteSyncer {
+func getLogWriter() (zapcore.WriteSyncer, error) {
 	path, err :=  os.Getwd()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	timeString := time.Now().Format("02-01-2006")
 	file, err := os.OpenFile(path + "/logs/" + timeString +".txt", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	return zapcore.AddSync(file)
+	return zapcore.AddSync(file), nil
 }
 
 func getEncoder() zapcore.Encoder {
@@ -61,39 +59,48 @@ func getEncoder() zapcore.Encoder {
 	return zapcore.NewConsoleEncoder(encoderConfig)
 }
 
-func getLevel() zapcore.Level {
-	err := LoadConfigSection("logger", &loggerConfig)
+func getLevel(config *app.Config) (zapcore.Level, error) {
+	var loggerConfig LoggerConfiguration
+	err := config.Section("logger", &loggerConfig)
 	if err != nil {
-		panic(err)
+		return zapcore.DebugLevel, err
 	}
 	if level, err := levelMap[loggerConfig.Level]; err {
-		return level
+		return level, nil
 	}
-	return zapcore.DebugLevel
+	return zapcore.DebugLevel, nil
 }
 
-func ChiLogger() func(next http.Handler) http.Handler {
+func ChiLogger(logg *zap.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 			next.ServeHTTP(ww, r)
-			logg.Info("Request:", zap.String("path", r.URL.Path), zap.Int("status", ww.Status()),
-				zap.Int("size", ww.BytesWritten()), zap.String("requestId", middleware.GetReqID(r.Context())))
+			fields := []zap.Field{zap.String("path", r.URL.Path), zap.Int("status", ww.Status()),
+				zap.Int("size", ww.BytesWritten()), zap.String("requestId", middleware.GetReqID(r.Context()))}
+			logg.Info("Request:", append(fields, tracing.LogFields(r.Context())...)...)
 		}
 		return http.HandlerFunc(fn)
 	}
 }
 
-func LoggerInitializer() {
+// NewLogger logs to stdout and to the file of the day in the logs dir. The handlers and services log with the one
+// on their context, see App.Context.
+func NewLogger(config *app.Config) (*zap.Logger, error) {
 	createDirectoryIfDoesntExist()
-	writerSync := getLogWriter()
+	writerSync, err := getLogWriter()
+	if err != nil {
+		return nil, err
+	}
+	level, err := getLevel(config)
+	if err != nil {
+		return nil, err
+	}
 	encoder := getEncoder()
 
 	core := zapcore.NewTee(
-		zapcore.NewCore(encoder, writerSync, getLevel()),
-		zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), getLevel()),
+		zapcore.NewCore(encoder, writerSync, level),
+		zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), level),
 	)
-	logg = zap.New(core, zap.AddCaller())
-
-	zap.ReplaceGlobals(logg)
+	return zap.New(core, zap.AddCaller()), nil
 }
\ No newline at end of file
@@ -0,0 +1,93 @@
+package initializers
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/pkg/app"
+	"github.com/rgraterol/beers-api/pkg/metrics"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
+)
+
+const metricsShutdownTimeout = 5 * time.Second
+
+// MetricsConfiguration represents the metrics configuration.
+type MetricsConfiguration struct {
+	// Address is where /metrics is served apart from the API, empty disables it.
+	Address string `yaml:"address"`
+}
+
+// NewMetricsServer serves the metrics of registry on /metrics, along with the counters of the rates cache when it
+// is not nil. The server is nil when the endpoint is disabled.
+func NewMetricsServer(config *app.Config, logger *zap.Logger, registry *metrics.Registry, cache rates.StatsInterface) (*http.Server, error) {
+	var metricsConfig MetricsConfiguration
+	err := config.Section("metrics", &metricsConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the metrics config")
+	}
+	log := logger.Sugar()
+	if metricsConfig.Address == "" {
+		log.Info("the metrics endpoint is disabled")
+		return nil, nil
+	}
+
+	// Listening right away reports a taken address as a startup error
+	listener, err := net.Listen("tcp", metricsConfig.Address)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to listen for metrics")
+	}
+	// The registry belongs to a single app, so the apps of a process never replace each other's cache counters
+	if cache != nil {
+		registerRatesCacheMetrics(registry, cache)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", metrics.HandlerFor(registry))
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Error("the metrics server stopped ", err)
+		}
+	}()
+	log.Info("metrics served on address ", metricsConfig.Address)
+	return server, nil
+}
+
+// registerRatesCacheMetrics reports the counters of cache on registry.
+func registerRatesCacheMetrics(registry *metrics.Registry, cache rates.StatsInterface) {
+	stat := func(value func(stats rates.CacheStats) float64) func() float64 {
+		return func() float64 {
+			return value(cache.Stats())
+		}
+	}
+	metrics.NewCounterFunc(registry, "rates_cache_hits_total", "Fresh rates served from cache.",
+		stat(func(s rates.CacheStats) float64 { return float64(s.Hits) }))
+	metrics.NewCounterFunc(registry, "rates_cache_stale_hits_total",
+		"Expired rates served while they are refreshed.",
+		stat(func(s rates.CacheStats) float64 { return float64(s.StaleHits) }))
+	metrics.NewCounterFunc(registry, "rates_cache_misses_total",
+		"Requests that waited for the rates.",
+		stat(func(s rates.CacheStats) float64 { return float64(s.Misses) }))
+	metrics.NewCounterFunc(registry, "rates_cache_refreshes_total", "Rates fetched from the provider.",
+		stat(func(s rates.CacheStats) float64 { return float64(s.Refreshes) }))
+	metrics.NewCounterFunc(registry, "rates_cache_failures_total", "Failed rates fetches from the provider.",
+		stat(func(s rates.CacheStats) float64 { return float64(s.Failures) }))
+	metrics.NewGaugeFunc(registry, "rates_cache_age_seconds",
+		"Age of the cached rates, zero while the cache is empty.",
+		stat(func(s rates.CacheStats) float64 { return s.Age.Seconds() }))
+}
+
+// closeMetrics stops serving /metrics.
+func closeMetrics(server *http.Server) func() error {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), metricsShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			return errors.Wrap(err, "failed to stop the metrics server")
+		}
+		return nil
+	}
+}
@@ -0,0 +1,33 @@
+package initializers
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/metrics"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRatesCacheMetricsOfEachApp(t *testing.T) {
+	// Given
+	ecb := rates.ProviderFunc(func(context.Context) (*rates.Rates, error) {
+		return &rates.Rates{Base: "EUR", Source: "ecb", Rates: map[string]money.Decimal{"USD": money.RequireFromString("1.1448")}}, nil
+	})
+	first, second := rates.NewCache(ecb, time.Hour, 0, 0), rates.NewCache(ecb, time.Hour, 0, 0)
+	_, err := first.GetRates(context.Background())
+	assert.Nil(t, err)
+	firstRegistry, secondRegistry := &metrics.Registry{}, &metrics.Registry{}
+	// When
+	registerRatesCacheMetrics(firstRegistry, first)
+	registerRatesCacheMetrics(secondRegistry, second)
+	// Then
+	var firstOut, secondOut bytes.Buffer
+	firstRegistry.WriteTo(&firstOut)
+	secondRegistry.WriteTo(&secondOut)
+	assert.Contains(t, firstOut.String(), "rates_cache_misses_total 1\n")
+	assert.Contains(t, secondOut.String(), "rates_cache_misses_total 0\n")
+}
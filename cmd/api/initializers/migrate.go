@@ -0,0 +1,98 @@
+package initializers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/db/migrations"
+	"github.com/rgraterol/beers-api/pkg/db"
+	"github.com/rgraterol/beers-api/pkg/metrics"
+	"github.com/rgraterol/beers-api/pkg/tracing"
+)
+
+const migrateUsage = "usage: beers-api migrate up | down [STEPS] | to VERSION | force VERSION | status"
+
+// Migrate runs the migrate subcommand of args on the DB of the environment config, then writes the schema status
+// to out.
+func Migrate(args []string, out io.Writer) (err error) {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	config, err := NewConfig()
+	if err != nil {
+		return err
+	}
+	logger, err := NewLogger(config)
+	if err != nil {
+		return err
+	}
+	defer logger.Sync()
+	databaseConfig, err := loadDatabaseConfig(config)
+	if err != nil {
+		return err
+	}
+	if databaseConfig.Demo {
+		return errors.New("the demo DB is created from the models, it has no migrations")
+	}
+	// The subcommand serves no metrics
+	g, err := openDatabase(databaseConfig, &metrics.Registry{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := closeDatabase(g)(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	ctx := tracing.ContextWithLogger(context.Background(), logger)
+	migrator := db.NewMigrator(g, migrations.FS)
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		err = migrator.Up(ctx)
+	case args[0] == "down" && len(args) <= 2:
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return errors.New("invalid steps " + args[1])
+			}
+		}
+		err = migrator.Down(ctx, steps)
+	case (args[0] == "to" || args[0] == "force") && len(args) == 2:
+		version, parseErr := strconv.ParseUint(args[1], 10, 64)
+		if parseErr != nil {
+			return errors.New("invalid version " + args[1])
+		}
+		if args[0] == "to" {
+			err = migrator.To(ctx, version)
+		} else {
+			err = migrator.Force(ctx, version)
+		}
+	case args[0] == "status" && len(args) == 1:
+	default:
+		return errors.New(migrateUsage)
+	}
+	if err != nil {
+		return err
+	}
+	return writeMigrationStatus(ctx, migrator, out)
+}
+
+func writeMigrationStatus(ctx context.Context, migrator *db.Migrator, out io.Writer) error {
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	dirty := ""
+	if status.Dirty {
+		dirty = " (dirty)"
+	}
+	fmt.Fprintf(out, "schema version %d%s, latest %d\n", status.Version, dirty, status.Latest)
+	for _, migration := range status.Pending {
+		fmt.Fprintf(out, "pending %06d_%s\n", migration.Version, migration.Name)
+	}
+	return nil
+}
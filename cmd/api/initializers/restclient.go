@@ -3,11 +3,11 @@ package initializers
 import (
 	"net/http"
 
+	"github.com/rgraterol/beers-api/pkg/metrics"
 	"github.com/rgraterol/beers-api/pkg/restclient"
-	"github.com/rgraterol/beers-api/pkg/usecases/currencylayer"
 )
 
-func RestClientsInitializer() {
-	restclient.Client = &http.Client{}
-	currencylayer.Layer = &currencylayer.ProductiveLayer{}
+// NewRestClient answers the client of the upstream APIs, measured on registry.
+func NewRestClient(registry *metrics.Registry) *restclient.Client {
+	return restclient.New(&http.Client{}, registry)
 }
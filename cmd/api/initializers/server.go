@@ -1,41 +1,157 @@
 package initializers
 
 import (
-	"errors"
-	"go.uber.org/zap"
+	"context"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/pkg/app"
+	"github.com/rgraterol/beers-api/pkg/metrics"
+	"github.com/rgraterol/beers-api/pkg/responses"
 	"github.com/rgraterol/beers-api/pkg/router"
+	"github.com/rgraterol/beers-api/pkg/tracing"
 )
 
-var serverConfig ServerConfiguration
-
-// ServerConfiguration represents a server configuration.
+// ServerConfiguration represents a server configuration, the timeouts are in seconds and zero disables them.
 type ServerConfiguration struct {
 	// Address is where the Server will listen
 	Address string `yaml:"address"`
 	// Timeout for all requests.
 	Timeout int `yaml:"timeout"`
+	// ExportTimeout replaces Timeout for the exports, which stream the whole catalog. Zero keeps them under Timeout.
+	ExportTimeout int `yaml:"exportTimeout"`
+	// ProblemDetails answers every error as RFC 7807 application/problem+json, otherwise only the requests
+	// accepting it get them.
+	ProblemDetails bool `yaml:"problemDetails"`
+	// ReadTimeout is the time to read a whole request, body included.
+	ReadTimeout int `yaml:"readTimeout"`
+	// ReadHeaderTimeout is the time to read the request headers.
+	ReadHeaderTimeout int `yaml:"readHeaderTimeout"`
+	// WriteTimeout is the time to write a response, it should be longer than Timeout and ExportTimeout.
+	WriteTimeout int `yaml:"writeTimeout"`
+	// IdleTimeout is the time a keep-alive connection waits for the next request.
+	IdleTimeout int `yaml:"idleTimeout"`
+	// ShutdownTimeout is the grace period the in-flight requests have to finish on SIGINT or SIGTERM.
+	ShutdownTimeout int `yaml:"shutdownTimeout"`
+	// DrainDelay is the time readiness reports draining before the server stops taking new connections, so the
+	// load balancers take the app out first.
+	DrainDelay int `yaml:"drainDelay"`
 }
 
-func ServerInitializer() {
-	err := LoadConfigSection("server", &serverConfig)
+// Serve serves the API of a on the configured address until SIGINT or SIGTERM.
+func Serve(a *app.App) error {
+	serverConfig, err := loadServerConfig(a.Config)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", serverConfig.Address)
 	if err != nil {
-		panic(errors.New("failed to read the server config"))
+		return errors.Wrap(err, "cannot listen on " + serverConfig.Address)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		// A second signal kills the app right away
+		stop()
+	}()
+	return ServeListener(ctx, a, listener)
+}
+
+// ServeListener serves the API of a on listener until ctx is done, then it drains the connections within the
+// shutdown grace period.
+func ServeListener(ctx context.Context, a *app.App, listener net.Listener) error {
+	serverConfig, err := loadServerConfig(a.Config)
+	if err != nil {
+		return err
 	}
 
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
+	r.Use(metrics.Middleware(a.Metrics))
+	r.Use(tracing.Middleware(a.Tracer))
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(time.Duration(serverConfig.Timeout) * time.Second))
-	r.Use(ChiLogger())
+	r.Use(requestTimeout(serverConfig))
+	r.Use(ChiLogger(a.Logger))
+	r.Use(responses.ProblemNegotiation(a.ProblemDetails))
+
+	router.Routes(r, a)
+
+	server := &http.Server{
+		Handler:           r,
+		ReadTimeout:       seconds(serverConfig.ReadTimeout),
+		ReadHeaderTimeout: seconds(serverConfig.ReadHeaderTimeout),
+		WriteTimeout:      seconds(serverConfig.WriteTimeout),
+		IdleTimeout:       seconds(serverConfig.IdleTimeout),
+		// The requests log with the logger of a
+		BaseContext: func(net.Listener) context.Context {
+			return a.Context(context.Background())
+		},
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+	logger := a.Logger.Sugar()
+	logger.Info("Application running on address ", listener.Addr(), " and enviroment ", a.Config.Env)
+
+	select {
+	case err = <-served:
+		return errors.Wrap(err, "the server stopped")
+	case <-ctx.Done():
+	}
+	a.Readiness.Drain()
+	if delay := seconds(serverConfig.DrainDelay); delay > 0 {
+		logger.Info("draining, new connections are taken for ", delay)
+		time.Sleep(delay)
+	}
+	grace := seconds(serverConfig.ShutdownTimeout)
+	logger.Info("shutting down, draining the connections for up to ", grace)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		return errors.Wrap(err, "failed to drain the server connections")
+	}
+	logger.Info("server stopped")
+	return nil
+}
 
-	router.Routes(r)
+// requestTimeout cuts the requests after the configured timeout, the exports get their own one.
+func requestTimeout(serverConfig *ServerConfiguration) func(next http.Handler) http.Handler {
+	exportTimeout := serverConfig.ExportTimeout
+	if exportTimeout == 0 {
+		exportTimeout = serverConfig.Timeout
+	}
+	return func(next http.Handler) http.Handler {
+		requests := middleware.Timeout(seconds(serverConfig.Timeout))(next)
+		exports := middleware.Timeout(seconds(exportTimeout))(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == router.ExportPath {
+				exports.ServeHTTP(w, r)
+				return
+			}
+			requests.ServeHTTP(w, r)
+		})
+	}
+}
+
+func loadServerConfig(config *app.Config) (*ServerConfiguration, error) {
+	var serverConfig ServerConfiguration
+	err := config.Section("server", &serverConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the server config")
+	}
+	return &serverConfig, nil
+}
 
-	zap.S().Info("Application running on address ", serverConfig.Address, " and enviroment ", Env())
-	http.ListenAndServe(serverConfig.Address, r)
+func seconds(s int) time.Duration {
+	return time.Duration(s) * time.Second
 }
@@ -0,0 +1,77 @@
+package initializers
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/app"
+	"github.com/rgraterol/beers-api/pkg/health"
+	"github.com/rgraterol/beers-api/pkg/metrics"
+	"github.com/rgraterol/beers-api/pkg/usecases/beers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeListenerDrainsInFlightRequests(t *testing.T) {
+	// Given
+	config, err := app.NewConfig("test", []byte("server:\n  timeout: 5\n  shutdownTimeout: 5\n"))
+	assert.Nil(t, err)
+	repository := &slowRepository{BeerRepository: beers.NewMemoryRepository(), started: make(chan struct{})}
+	a := &app.App{Config: config, Logger: zap.NewNop(), Metrics: &metrics.Registry{}, Beers: repository, Liveness: &health.Checker{}, Readiness: &health.Checker{}}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	served := make(chan error, 1)
+	go func() {
+		served <- ServeListener(ctx, a, listener)
+	}()
+	statuses := make(chan int, 1)
+	go func() {
+		res, err := http.Get("http://" + listener.Addr().String() + "/beers")
+		if err != nil {
+			statuses <- 0
+			return
+		}
+		res.Body.Close()
+		statuses <- res.StatusCode
+	}()
+	<-repository.started
+	// When
+	cancel()
+	// Then
+	assert.Equal(t, http.StatusOK, <-statuses)
+	assert.Nil(t, <-served)
+	assert.True(t, a.Readiness.Draining())
+}
+
+// slowRepository takes a while to count the beers, keeping the request in flight.
+type slowRepository struct {
+	beers.BeerRepository
+	started chan struct{}
+}
+
+func (r *slowRepository) Count(ctx context.Context, params *beers.BeerListParameters) (int64, error) {
+	close(r.started)
+	time.Sleep(200 * time.Millisecond)
+	return r.BeerRepository.Count(ctx, params)
+}
+
+func TestRequestTimeoutOfTheExports(t *testing.T) {
+	// Given
+	deadlines := map[string]time.Duration{}
+	handler := requestTimeout(&ServerConfiguration{Timeout: 10, ExportTimeout: 300})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ := r.Context().Deadline()
+		deadlines[r.URL.Path] = time.Until(deadline)
+	}))
+	// When
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/beers", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/beers/export", nil))
+	// Then
+	assert.InDelta(t, 10*time.Second, deadlines["/beers"], float64(time.Second))
+	assert.InDelta(t, 300*time.Second, deadlines["/beers/export"], float64(time.Second))
+}
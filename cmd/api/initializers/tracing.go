@@ -0,0 +1,90 @@
+package initializers
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/pkg/app"
+	"github.com/rgraterol/beers-api/pkg/tracing"
+)
+
+const (
+	noneExporter   = "none"
+	stdoutExporter = "stdout"
+	otlpExporter   = "otlp"
+
+	defaultServiceName     = "beers-api"
+	defaultOTLPEndpoint    = "http://localhost:4318"
+	defaultTracingTimeout  = 10
+	tracingShutdownTimeout = 5 * time.Second
+)
+
+// TracingConfiguration represents the tracing configuration.
+type TracingConfiguration struct {
+	// Exporter of the spans, can be none, stdout or otlp.
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the OTLP/HTTP collector address, it can be overridden with OTEL_EXPORTER_OTLP_ENDPOINT.
+	Endpoint string `yaml:"endpoint"`
+	// Timeout is the time in seconds an export to the collector can take.
+	Timeout int `yaml:"timeout"`
+	// ServiceName names the app on the collector.
+	ServiceName string `yaml:"serviceName"`
+	// SampleRatio is the share of the new traces exported, between 0 and 1. Incoming traces keep their decision.
+	SampleRatio float64 `yaml:"sampleRatio"`
+}
+
+// NewTracingProvider exports the spans to the configured exporter, it is nil without exporter. The trace ids are
+// propagated and logged either way.
+func NewTracingProvider(config *app.Config, logger *zap.Logger) (*tracing.Provider, error) {
+	var tracingConfig TracingConfiguration
+	err := config.Section("tracing", &tracingConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the tracing config")
+	}
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		tracingConfig.Endpoint = endpoint
+	}
+	exporter, err := newSpanExporter(&tracingConfig)
+	if err != nil || exporter == nil {
+		return nil, err
+	}
+	logger.Sugar().Info("spans exported to ", tracingConfig.Exporter)
+	return tracing.NewProvider(exporter, tracingConfig.SampleRatio, logger), nil
+}
+
+func newSpanExporter(config *TracingConfiguration) (tracing.Exporter, error) {
+	switch config.Exporter {
+	case noneExporter, "":
+		return nil, nil
+	case stdoutExporter:
+		return &tracing.StdoutExporter{Writer: os.Stdout}, nil
+	case otlpExporter:
+		endpoint, serviceName, timeout := config.Endpoint, config.ServiceName, config.Timeout
+		if endpoint == "" {
+			endpoint = defaultOTLPEndpoint
+		}
+		if serviceName == "" {
+			serviceName = defaultServiceName
+		}
+		if timeout <= 0 {
+			timeout = defaultTracingTimeout
+		}
+		return tracing.NewOTLPExporter(endpoint, serviceName, seconds(timeout)), nil
+	}
+	return nil, errors.New("unknown tracing exporter " + config.Exporter)
+}
+
+// closeTracing exports the spans left.
+func closeTracing(provider *tracing.Provider) func() error {
+	return func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			return errors.Wrap(err, "failed to export the last spans")
+		}
+		return nil
+	}
+}
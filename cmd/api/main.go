@@ -1,17 +1,31 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	i "github.com/rgraterol/beers-api/cmd/api/initializers"
 )
 
 func main() {
-	run()
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
 
-func run() {
-	i.ConfigInitializer()
-	i.LoggerInitializer()
-	i.DatabaseInitializer()
-	i.RestClientsInitializer()
-	i.ServerInitializer()
+func run() (err error) {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		return i.Migrate(os.Args[2:], os.Stdout)
+	}
+	a, err := i.NewApp()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := a.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	return i.Serve(a)
 }
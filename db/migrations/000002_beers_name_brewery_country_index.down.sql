@@ -0,0 +1,2 @@
+DROP INDEX idx_beers_deleted_at ON beers;
+DROP INDEX idx_name_brewery_country ON beers;
@@ -0,0 +1,2 @@
+CREATE INDEX idx_name_brewery_country ON beers (name, brewery, country);
+CREATE INDEX idx_beers_deleted_at ON beers (deleted_at);
@@ -0,0 +1,11 @@
+CREATE TABLE exchange_rates (
+   id BIGINT PRIMARY KEY NOT NULL AUTO_INCREMENT,
+   base VARCHAR(3),
+   currency VARCHAR(3),
+   rate DOUBLE NOT NULL,
+   rated_at TIMESTAMP NULL,
+   source VARCHAR(20),
+   created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_exchange_rates_snapshot ON exchange_rates (rated_at, base);
@@ -0,0 +1 @@
+DROP INDEX idx_exchange_rates_currency ON exchange_rates;
@@ -0,0 +1 @@
+CREATE INDEX idx_exchange_rates_currency ON exchange_rates (currency, rated_at);
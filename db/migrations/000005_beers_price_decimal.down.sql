@@ -0,0 +1 @@
+ALTER TABLE beers MODIFY price FLOAT;
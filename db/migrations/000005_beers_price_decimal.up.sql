@@ -0,0 +1 @@
+ALTER TABLE beers MODIFY price DECIMAL(20,6);
@@ -0,0 +1,2 @@
+DROP INDEX idx_beers_live_key ON beers;
+ALTER TABLE beers DROP COLUMN live;
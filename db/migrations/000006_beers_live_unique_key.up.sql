@@ -0,0 +1,2 @@
+ALTER TABLE beers ADD COLUMN live TINYINT GENERATED ALWAYS AS (CASE WHEN deleted_at IS NULL THEN 1 END) STORED;
+CREATE UNIQUE INDEX idx_beers_live_key ON beers (name, brewery, country, live);
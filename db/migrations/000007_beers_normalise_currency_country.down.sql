@@ -0,0 +1,6 @@
+UPDATE beers SET
+    country = (SELECT o.country FROM beers_original_spellings o WHERE o.id = beers.id),
+    currency = (SELECT o.currency FROM beers_original_spellings o WHERE o.id = beers.id),
+    deleted_at = (SELECT o.deleted_at FROM beers_original_spellings o WHERE o.id = beers.id)
+WHERE id IN (SELECT id FROM beers_original_spellings);
+DROP TABLE beers_original_spellings;
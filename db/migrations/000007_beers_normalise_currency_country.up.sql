@@ -0,0 +1,268 @@
+-- The spellings are kept on beers_original_spellings for the down migration
+CREATE TABLE beers_original_spellings AS SELECT id, country, currency, deleted_at FROM beers;
+CREATE TABLE beers_normalised AS SELECT id, name, brewery, country, currency, deleted_at FROM beers;
+UPDATE beers_normalised SET currency = UPPER(TRIM(currency)) WHERE LENGTH(TRIM(currency)) = 3;
+UPDATE beers_normalised SET country = CASE
+    WHEN LOWER(TRIM(country)) IN ('af', 'afg', 'afghanistan') THEN 'Afghanistan'
+    WHEN LOWER(TRIM(country)) IN ('ax', 'ala', 'åland islands', 'aland islands') THEN 'Åland Islands'
+    WHEN LOWER(TRIM(country)) IN ('al', 'alb', 'albania') THEN 'Albania'
+    WHEN LOWER(TRIM(country)) IN ('dz', 'dza', 'algeria') THEN 'Algeria'
+    WHEN LOWER(TRIM(country)) IN ('as', 'asm', 'american samoa') THEN 'American Samoa'
+    WHEN LOWER(TRIM(country)) IN ('ad', 'and', 'andorra') THEN 'Andorra'
+    WHEN LOWER(TRIM(country)) IN ('ao', 'ago', 'angola') THEN 'Angola'
+    WHEN LOWER(TRIM(country)) IN ('ai', 'aia', 'anguilla') THEN 'Anguilla'
+    WHEN LOWER(TRIM(country)) IN ('aq', 'ata', 'antarctica') THEN 'Antarctica'
+    WHEN LOWER(TRIM(country)) IN ('ag', 'atg', 'antigua and barbuda') THEN 'Antigua and Barbuda'
+    WHEN LOWER(TRIM(country)) IN ('ar', 'arg', 'argentina') THEN 'Argentina'
+    WHEN LOWER(TRIM(country)) IN ('am', 'arm', 'armenia') THEN 'Armenia'
+    WHEN LOWER(TRIM(country)) IN ('aw', 'abw', 'aruba') THEN 'Aruba'
+    WHEN LOWER(TRIM(country)) IN ('au', 'aus', 'australia') THEN 'Australia'
+    WHEN LOWER(TRIM(country)) IN ('at', 'aut', 'austria') THEN 'Austria'
+    WHEN LOWER(TRIM(country)) IN ('az', 'aze', 'azerbaijan') THEN 'Azerbaijan'
+    WHEN LOWER(TRIM(country)) IN ('bs', 'bhs', 'bahamas') THEN 'Bahamas'
+    WHEN LOWER(TRIM(country)) IN ('bh', 'bhr', 'bahrain') THEN 'Bahrain'
+    WHEN LOWER(TRIM(country)) IN ('bd', 'bgd', 'bangladesh') THEN 'Bangladesh'
+    WHEN LOWER(TRIM(country)) IN ('bb', 'brb', 'barbados') THEN 'Barbados'
+    WHEN LOWER(TRIM(country)) IN ('by', 'blr', 'belarus') THEN 'Belarus'
+    WHEN LOWER(TRIM(country)) IN ('be', 'bel', 'belgium') THEN 'Belgium'
+    WHEN LOWER(TRIM(country)) IN ('bz', 'blz', 'belize') THEN 'Belize'
+    WHEN LOWER(TRIM(country)) IN ('bj', 'ben', 'benin') THEN 'Benin'
+    WHEN LOWER(TRIM(country)) IN ('bm', 'bmu', 'bermuda') THEN 'Bermuda'
+    WHEN LOWER(TRIM(country)) IN ('bt', 'btn', 'bhutan') THEN 'Bhutan'
+    WHEN LOWER(TRIM(country)) IN ('bo', 'bol', 'bolivia, plurinational state of', 'bolivia') THEN 'Bolivia, Plurinational State of'
+    WHEN LOWER(TRIM(country)) IN ('bq', 'bes', 'bonaire, sint eustatius and saba') THEN 'Bonaire, Sint Eustatius and Saba'
+    WHEN LOWER(TRIM(country)) IN ('ba', 'bih', 'bosnia and herzegovina') THEN 'Bosnia and Herzegovina'
+    WHEN LOWER(TRIM(country)) IN ('bw', 'bwa', 'botswana') THEN 'Botswana'
+    WHEN LOWER(TRIM(country)) IN ('bv', 'bvt', 'bouvet island') THEN 'Bouvet Island'
+    WHEN LOWER(TRIM(country)) IN ('br', 'bra', 'brazil') THEN 'Brazil'
+    WHEN LOWER(TRIM(country)) IN ('io', 'iot', 'british indian ocean territory') THEN 'British Indian Ocean Territory'
+    WHEN LOWER(TRIM(country)) IN ('bn', 'brn', 'brunei darussalam', 'brunei') THEN 'Brunei Darussalam'
+    WHEN LOWER(TRIM(country)) IN ('bg', 'bgr', 'bulgaria') THEN 'Bulgaria'
+    WHEN LOWER(TRIM(country)) IN ('bf', 'bfa', 'burkina faso') THEN 'Burkina Faso'
+    WHEN LOWER(TRIM(country)) IN ('bi', 'bdi', 'burundi') THEN 'Burundi'
+    WHEN LOWER(TRIM(country)) IN ('cv', 'cpv', 'cabo verde', 'cape verde') THEN 'Cabo Verde'
+    WHEN LOWER(TRIM(country)) IN ('kh', 'khm', 'cambodia') THEN 'Cambodia'
+    WHEN LOWER(TRIM(country)) IN ('cm', 'cmr', 'cameroon') THEN 'Cameroon'
+    WHEN LOWER(TRIM(country)) IN ('ca', 'can', 'canada') THEN 'Canada'
+    WHEN LOWER(TRIM(country)) IN ('ky', 'cym', 'cayman islands') THEN 'Cayman Islands'
+    WHEN LOWER(TRIM(country)) IN ('cf', 'caf', 'central african republic') THEN 'Central African Republic'
+    WHEN LOWER(TRIM(country)) IN ('td', 'tcd', 'chad') THEN 'Chad'
+    WHEN LOWER(TRIM(country)) IN ('cl', 'chl', 'chile') THEN 'Chile'
+    WHEN LOWER(TRIM(country)) IN ('cn', 'chn', 'china') THEN 'China'
+    WHEN LOWER(TRIM(country)) IN ('cx', 'cxr', 'christmas island') THEN 'Christmas Island'
+    WHEN LOWER(TRIM(country)) IN ('cc', 'cck', 'cocos (keeling) islands') THEN 'Cocos (Keeling) Islands'
+    WHEN LOWER(TRIM(country)) IN ('co', 'col', 'colombia') THEN 'Colombia'
+    WHEN LOWER(TRIM(country)) IN ('km', 'com', 'comoros') THEN 'Comoros'
+    WHEN LOWER(TRIM(country)) IN ('cg', 'cog', 'congo') THEN 'Congo'
+    WHEN LOWER(TRIM(country)) IN ('cd', 'cod', 'congo, the democratic republic of the', 'dr congo') THEN 'Congo, The Democratic Republic of the'
+    WHEN LOWER(TRIM(country)) IN ('ck', 'cok', 'cook islands') THEN 'Cook Islands'
+    WHEN LOWER(TRIM(country)) IN ('cr', 'cri', 'costa rica') THEN 'Costa Rica'
+    WHEN LOWER(TRIM(country)) IN ('ci', 'civ', 'côte d''ivoire', 'cote d''ivoire', 'ivory coast') THEN 'Côte d''Ivoire'
+    WHEN LOWER(TRIM(country)) IN ('hr', 'hrv', 'croatia') THEN 'Croatia'
+    WHEN LOWER(TRIM(country)) IN ('cu', 'cub', 'cuba') THEN 'Cuba'
+    WHEN LOWER(TRIM(country)) IN ('cw', 'cuw', 'curaçao', 'curacao') THEN 'Curaçao'
+    WHEN LOWER(TRIM(country)) IN ('cy', 'cyp', 'cyprus') THEN 'Cyprus'
+    WHEN LOWER(TRIM(country)) IN ('cz', 'cze', 'czechia', 'czech republic') THEN 'Czechia'
+    WHEN LOWER(TRIM(country)) IN ('dk', 'dnk', 'denmark') THEN 'Denmark'
+    WHEN LOWER(TRIM(country)) IN ('dj', 'dji', 'djibouti') THEN 'Djibouti'
+    WHEN LOWER(TRIM(country)) IN ('dm', 'dma', 'dominica') THEN 'Dominica'
+    WHEN LOWER(TRIM(country)) IN ('do', 'dom', 'dominican republic') THEN 'Dominican Republic'
+    WHEN LOWER(TRIM(country)) IN ('ec', 'ecu', 'ecuador') THEN 'Ecuador'
+    WHEN LOWER(TRIM(country)) IN ('eg', 'egy', 'egypt') THEN 'Egypt'
+    WHEN LOWER(TRIM(country)) IN ('sv', 'slv', 'el salvador') THEN 'El Salvador'
+    WHEN LOWER(TRIM(country)) IN ('gq', 'gnq', 'equatorial guinea') THEN 'Equatorial Guinea'
+    WHEN LOWER(TRIM(country)) IN ('er', 'eri', 'eritrea') THEN 'Eritrea'
+    WHEN LOWER(TRIM(country)) IN ('ee', 'est', 'estonia') THEN 'Estonia'
+    WHEN LOWER(TRIM(country)) IN ('sz', 'swz', 'eswatini', 'swaziland') THEN 'Eswatini'
+    WHEN LOWER(TRIM(country)) IN ('et', 'eth', 'ethiopia') THEN 'Ethiopia'
+    WHEN LOWER(TRIM(country)) IN ('fk', 'flk', 'falkland islands (malvinas)', 'falkland islands') THEN 'Falkland Islands (Malvinas)'
+    WHEN LOWER(TRIM(country)) IN ('fo', 'fro', 'faroe islands') THEN 'Faroe Islands'
+    WHEN LOWER(TRIM(country)) IN ('fj', 'fji', 'fiji') THEN 'Fiji'
+    WHEN LOWER(TRIM(country)) IN ('fi', 'fin', 'finland') THEN 'Finland'
+    WHEN LOWER(TRIM(country)) IN ('fr', 'fra', 'france') THEN 'France'
+    WHEN LOWER(TRIM(country)) IN ('gf', 'guf', 'french guiana') THEN 'French Guiana'
+    WHEN LOWER(TRIM(country)) IN ('pf', 'pyf', 'french polynesia') THEN 'French Polynesia'
+    WHEN LOWER(TRIM(country)) IN ('tf', 'atf', 'french southern territories') THEN 'French Southern Territories'
+    WHEN LOWER(TRIM(country)) IN ('ga', 'gab', 'gabon') THEN 'Gabon'
+    WHEN LOWER(TRIM(country)) IN ('gm', 'gmb', 'gambia') THEN 'Gambia'
+    WHEN LOWER(TRIM(country)) IN ('ge', 'geo', 'georgia') THEN 'Georgia'
+    WHEN LOWER(TRIM(country)) IN ('de', 'deu', 'germany') THEN 'Germany'
+    WHEN LOWER(TRIM(country)) IN ('gh', 'gha', 'ghana') THEN 'Ghana'
+    WHEN LOWER(TRIM(country)) IN ('gi', 'gib', 'gibraltar') THEN 'Gibraltar'
+    WHEN LOWER(TRIM(country)) IN ('gr', 'grc', 'greece') THEN 'Greece'
+    WHEN LOWER(TRIM(country)) IN ('gl', 'grl', 'greenland') THEN 'Greenland'
+    WHEN LOWER(TRIM(country)) IN ('gd', 'grd', 'grenada') THEN 'Grenada'
+    WHEN LOWER(TRIM(country)) IN ('gp', 'glp', 'guadeloupe') THEN 'Guadeloupe'
+    WHEN LOWER(TRIM(country)) IN ('gu', 'gum', 'guam') THEN 'Guam'
+    WHEN LOWER(TRIM(country)) IN ('gt', 'gtm', 'guatemala') THEN 'Guatemala'
+    WHEN LOWER(TRIM(country)) IN ('gg', 'ggy', 'guernsey') THEN 'Guernsey'
+    WHEN LOWER(TRIM(country)) IN ('gn', 'gin', 'guinea') THEN 'Guinea'
+    WHEN LOWER(TRIM(country)) IN ('gw', 'gnb', 'guinea-bissau') THEN 'Guinea-Bissau'
+    WHEN LOWER(TRIM(country)) IN ('gy', 'guy', 'guyana') THEN 'Guyana'
+    WHEN LOWER(TRIM(country)) IN ('ht', 'hti', 'haiti') THEN 'Haiti'
+    WHEN LOWER(TRIM(country)) IN ('hm', 'hmd', 'heard island and mcdonald islands') THEN 'Heard Island and McDonald Islands'
+    WHEN LOWER(TRIM(country)) IN ('va', 'vat', 'holy see (vatican city state)', 'vatican city') THEN 'Holy See (Vatican City State)'
+    WHEN LOWER(TRIM(country)) IN ('hn', 'hnd', 'honduras') THEN 'Honduras'
+    WHEN LOWER(TRIM(country)) IN ('hk', 'hkg', 'hong kong') THEN 'Hong Kong'
+    WHEN LOWER(TRIM(country)) IN ('hu', 'hun', 'hungary') THEN 'Hungary'
+    WHEN LOWER(TRIM(country)) IN ('is', 'isl', 'iceland') THEN 'Iceland'
+    WHEN LOWER(TRIM(country)) IN ('in', 'ind', 'india') THEN 'India'
+    WHEN LOWER(TRIM(country)) IN ('id', 'idn', 'indonesia') THEN 'Indonesia'
+    WHEN LOWER(TRIM(country)) IN ('ir', 'irn', 'iran, islamic republic of', 'iran') THEN 'Iran, Islamic Republic of'
+    WHEN LOWER(TRIM(country)) IN ('iq', 'irq', 'iraq') THEN 'Iraq'
+    WHEN LOWER(TRIM(country)) IN ('ie', 'irl', 'ireland') THEN 'Ireland'
+    WHEN LOWER(TRIM(country)) IN ('im', 'imn', 'isle of man') THEN 'Isle of Man'
+    WHEN LOWER(TRIM(country)) IN ('il', 'isr', 'israel') THEN 'Israel'
+    WHEN LOWER(TRIM(country)) IN ('it', 'ita', 'italy') THEN 'Italy'
+    WHEN LOWER(TRIM(country)) IN ('jm', 'jam', 'jamaica') THEN 'Jamaica'
+    WHEN LOWER(TRIM(country)) IN ('jp', 'jpn', 'japan') THEN 'Japan'
+    WHEN LOWER(TRIM(country)) IN ('je', 'jey', 'jersey') THEN 'Jersey'
+    WHEN LOWER(TRIM(country)) IN ('jo', 'jor', 'jordan') THEN 'Jordan'
+    WHEN LOWER(TRIM(country)) IN ('kz', 'kaz', 'kazakhstan') THEN 'Kazakhstan'
+    WHEN LOWER(TRIM(country)) IN ('ke', 'ken', 'kenya') THEN 'Kenya'
+    WHEN LOWER(TRIM(country)) IN ('ki', 'kir', 'kiribati') THEN 'Kiribati'
+    WHEN LOWER(TRIM(country)) IN ('kp', 'prk', 'korea, democratic people''s republic of', 'north korea') THEN 'Korea, Democratic People''s Republic of'
+    WHEN LOWER(TRIM(country)) IN ('kr', 'kor', 'korea, republic of', 'south korea') THEN 'Korea, Republic of'
+    WHEN LOWER(TRIM(country)) IN ('kw', 'kwt', 'kuwait') THEN 'Kuwait'
+    WHEN LOWER(TRIM(country)) IN ('kg', 'kgz', 'kyrgyzstan') THEN 'Kyrgyzstan'
+    WHEN LOWER(TRIM(country)) IN ('la', 'lao', 'lao people''s democratic republic', 'laos') THEN 'Lao People''s Democratic Republic'
+    WHEN LOWER(TRIM(country)) IN ('lv', 'lva', 'latvia') THEN 'Latvia'
+    WHEN LOWER(TRIM(country)) IN ('lb', 'lbn', 'lebanon') THEN 'Lebanon'
+    WHEN LOWER(TRIM(country)) IN ('ls', 'lso', 'lesotho') THEN 'Lesotho'
+    WHEN LOWER(TRIM(country)) IN ('lr', 'lbr', 'liberia') THEN 'Liberia'
+    WHEN LOWER(TRIM(country)) IN ('ly', 'lby', 'libya') THEN 'Libya'
+    WHEN LOWER(TRIM(country)) IN ('li', 'lie', 'liechtenstein') THEN 'Liechtenstein'
+    WHEN LOWER(TRIM(country)) IN ('lt', 'ltu', 'lithuania') THEN 'Lithuania'
+    WHEN LOWER(TRIM(country)) IN ('lu', 'lux', 'luxembourg') THEN 'Luxembourg'
+    WHEN LOWER(TRIM(country)) IN ('mo', 'mac', 'macao', 'macau') THEN 'Macao'
+    WHEN LOWER(TRIM(country)) IN ('mg', 'mdg', 'madagascar') THEN 'Madagascar'
+    WHEN LOWER(TRIM(country)) IN ('mw', 'mwi', 'malawi') THEN 'Malawi'
+    WHEN LOWER(TRIM(country)) IN ('my', 'mys', 'malaysia') THEN 'Malaysia'
+    WHEN LOWER(TRIM(country)) IN ('mv', 'mdv', 'maldives') THEN 'Maldives'
+    WHEN LOWER(TRIM(country)) IN ('ml', 'mli', 'mali') THEN 'Mali'
+    WHEN LOWER(TRIM(country)) IN ('mt', 'mlt', 'malta') THEN 'Malta'
+    WHEN LOWER(TRIM(country)) IN ('mh', 'mhl', 'marshall islands') THEN 'Marshall Islands'
+    WHEN LOWER(TRIM(country)) IN ('mq', 'mtq', 'martinique') THEN 'Martinique'
+    WHEN LOWER(TRIM(country)) IN ('mr', 'mrt', 'mauritania') THEN 'Mauritania'
+    WHEN LOWER(TRIM(country)) IN ('mu', 'mus', 'mauritius') THEN 'Mauritius'
+    WHEN LOWER(TRIM(country)) IN ('yt', 'myt', 'mayotte') THEN 'Mayotte'
+    WHEN LOWER(TRIM(country)) IN ('mx', 'mex', 'mexico') THEN 'Mexico'
+    WHEN LOWER(TRIM(country)) IN ('fm', 'fsm', 'micronesia, federated states of', 'micronesia') THEN 'Micronesia, Federated States of'
+    WHEN LOWER(TRIM(country)) IN ('md', 'mda', 'moldova, republic of', 'moldova') THEN 'Moldova, Republic of'
+    WHEN LOWER(TRIM(country)) IN ('mc', 'mco', 'monaco') THEN 'Monaco'
+    WHEN LOWER(TRIM(country)) IN ('mn', 'mng', 'mongolia') THEN 'Mongolia'
+    WHEN LOWER(TRIM(country)) IN ('me', 'mne', 'montenegro') THEN 'Montenegro'
+    WHEN LOWER(TRIM(country)) IN ('ms', 'msr', 'montserrat') THEN 'Montserrat'
+    WHEN LOWER(TRIM(country)) IN ('ma', 'mar', 'morocco') THEN 'Morocco'
+    WHEN LOWER(TRIM(country)) IN ('mz', 'moz', 'mozambique') THEN 'Mozambique'
+    WHEN LOWER(TRIM(country)) IN ('mm', 'mmr', 'myanmar', 'burma') THEN 'Myanmar'
+    WHEN LOWER(TRIM(country)) IN ('na', 'nam', 'namibia') THEN 'Namibia'
+    WHEN LOWER(TRIM(country)) IN ('nr', 'nru', 'nauru') THEN 'Nauru'
+    WHEN LOWER(TRIM(country)) IN ('np', 'npl', 'nepal') THEN 'Nepal'
+    WHEN LOWER(TRIM(country)) IN ('nl', 'nld', 'netherlands', 'holland') THEN 'Netherlands'
+    WHEN LOWER(TRIM(country)) IN ('nc', 'ncl', 'new caledonia') THEN 'New Caledonia'
+    WHEN LOWER(TRIM(country)) IN ('nz', 'nzl', 'new zealand') THEN 'New Zealand'
+    WHEN LOWER(TRIM(country)) IN ('ni', 'nic', 'nicaragua') THEN 'Nicaragua'
+    WHEN LOWER(TRIM(country)) IN ('ne', 'ner', 'niger') THEN 'Niger'
+    WHEN LOWER(TRIM(country)) IN ('ng', 'nga', 'nigeria') THEN 'Nigeria'
+    WHEN LOWER(TRIM(country)) IN ('nu', 'niu', 'niue') THEN 'Niue'
+    WHEN LOWER(TRIM(country)) IN ('nf', 'nfk', 'norfolk island') THEN 'Norfolk Island'
+    WHEN LOWER(TRIM(country)) IN ('mk', 'mkd', 'north macedonia', 'macedonia') THEN 'North Macedonia'
+    WHEN LOWER(TRIM(country)) IN ('mp', 'mnp', 'northern mariana islands') THEN 'Northern Mariana Islands'
+    WHEN LOWER(TRIM(country)) IN ('no', 'nor', 'norway') THEN 'Norway'
+    WHEN LOWER(TRIM(country)) IN ('om', 'omn', 'oman') THEN 'Oman'
+    WHEN LOWER(TRIM(country)) IN ('pk', 'pak', 'pakistan') THEN 'Pakistan'
+    WHEN LOWER(TRIM(country)) IN ('pw', 'plw', 'palau') THEN 'Palau'
+    WHEN LOWER(TRIM(country)) IN ('ps', 'pse', 'palestine, state of', 'palestine') THEN 'Palestine, State of'
+    WHEN LOWER(TRIM(country)) IN ('pa', 'pan', 'panama') THEN 'Panama'
+    WHEN LOWER(TRIM(country)) IN ('pg', 'png', 'papua new guinea') THEN 'Papua New Guinea'
+    WHEN LOWER(TRIM(country)) IN ('py', 'pry', 'paraguay') THEN 'Paraguay'
+    WHEN LOWER(TRIM(country)) IN ('pe', 'per', 'peru') THEN 'Peru'
+    WHEN LOWER(TRIM(country)) IN ('ph', 'phl', 'philippines') THEN 'Philippines'
+    WHEN LOWER(TRIM(country)) IN ('pn', 'pcn', 'pitcairn') THEN 'Pitcairn'
+    WHEN LOWER(TRIM(country)) IN ('pl', 'pol', 'poland') THEN 'Poland'
+    WHEN LOWER(TRIM(country)) IN ('pt', 'prt', 'portugal') THEN 'Portugal'
+    WHEN LOWER(TRIM(country)) IN ('pr', 'pri', 'puerto rico') THEN 'Puerto Rico'
+    WHEN LOWER(TRIM(country)) IN ('qa', 'qat', 'qatar') THEN 'Qatar'
+    WHEN LOWER(TRIM(country)) IN ('re', 'reu', 'réunion', 'reunion') THEN 'Réunion'
+    WHEN LOWER(TRIM(country)) IN ('ro', 'rou', 'romania') THEN 'Romania'
+    WHEN LOWER(TRIM(country)) IN ('ru', 'rus', 'russian federation', 'russia') THEN 'Russian Federation'
+    WHEN LOWER(TRIM(country)) IN ('rw', 'rwa', 'rwanda') THEN 'Rwanda'
+    WHEN LOWER(TRIM(country)) IN ('bl', 'blm', 'saint barthélemy', 'saint barthelemy') THEN 'Saint Barthélemy'
+    WHEN LOWER(TRIM(country)) IN ('sh', 'shn', 'saint helena, ascension and tristan da cunha', 'saint helena') THEN 'Saint Helena, Ascension and Tristan da Cunha'
+    WHEN LOWER(TRIM(country)) IN ('kn', 'kna', 'saint kitts and nevis') THEN 'Saint Kitts and Nevis'
+    WHEN LOWER(TRIM(country)) IN ('lc', 'lca', 'saint lucia') THEN 'Saint Lucia'
+    WHEN LOWER(TRIM(country)) IN ('mf', 'maf', 'saint martin (french part)', 'saint martin') THEN 'Saint Martin (French part)'
+    WHEN LOWER(TRIM(country)) IN ('pm', 'spm', 'saint pierre and miquelon') THEN 'Saint Pierre and Miquelon'
+    WHEN LOWER(TRIM(country)) IN ('vc', 'vct', 'saint vincent and the grenadines') THEN 'Saint Vincent and the Grenadines'
+    WHEN LOWER(TRIM(country)) IN ('ws', 'wsm', 'samoa') THEN 'Samoa'
+    WHEN LOWER(TRIM(country)) IN ('sm', 'smr', 'san marino') THEN 'San Marino'
+    WHEN LOWER(TRIM(country)) IN ('st', 'stp', 'sao tome and principe') THEN 'Sao Tome and Principe'
+    WHEN LOWER(TRIM(country)) IN ('sa', 'sau', 'saudi arabia') THEN 'Saudi Arabia'
+    WHEN LOWER(TRIM(country)) IN ('sn', 'sen', 'senegal') THEN 'Senegal'
+    WHEN LOWER(TRIM(country)) IN ('rs', 'srb', 'serbia') THEN 'Serbia'
+    WHEN LOWER(TRIM(country)) IN ('sc', 'syc', 'seychelles') THEN 'Seychelles'
+    WHEN LOWER(TRIM(country)) IN ('sl', 'sle', 'sierra leone') THEN 'Sierra Leone'
+    WHEN LOWER(TRIM(country)) IN ('sg', 'sgp', 'singapore') THEN 'Singapore'
+    WHEN LOWER(TRIM(country)) IN ('sx', 'sxm', 'sint maarten (dutch part)', 'sint maarten') THEN 'Sint Maarten (Dutch part)'
+    WHEN LOWER(TRIM(country)) IN ('sk', 'svk', 'slovakia') THEN 'Slovakia'
+    WHEN LOWER(TRIM(country)) IN ('si', 'svn', 'slovenia') THEN 'Slovenia'
+    WHEN LOWER(TRIM(country)) IN ('sb', 'slb', 'solomon islands') THEN 'Solomon Islands'
+    WHEN LOWER(TRIM(country)) IN ('so', 'som', 'somalia') THEN 'Somalia'
+    WHEN LOWER(TRIM(country)) IN ('za', 'zaf', 'south africa') THEN 'South Africa'
+    WHEN LOWER(TRIM(country)) IN ('gs', 'sgs', 'south georgia and the south sandwich islands') THEN 'South Georgia and the South Sandwich Islands'
+    WHEN LOWER(TRIM(country)) IN ('ss', 'ssd', 'south sudan') THEN 'South Sudan'
+    WHEN LOWER(TRIM(country)) IN ('es', 'esp', 'spain') THEN 'Spain'
+    WHEN LOWER(TRIM(country)) IN ('lk', 'lka', 'sri lanka') THEN 'Sri Lanka'
+    WHEN LOWER(TRIM(country)) IN ('sd', 'sdn', 'sudan') THEN 'Sudan'
+    WHEN LOWER(TRIM(country)) IN ('sr', 'sur', 'suriname') THEN 'Suriname'
+    WHEN LOWER(TRIM(country)) IN ('sj', 'sjm', 'svalbard and jan mayen') THEN 'Svalbard and Jan Mayen'
+    WHEN LOWER(TRIM(country)) IN ('se', 'swe', 'sweden') THEN 'Sweden'
+    WHEN LOWER(TRIM(country)) IN ('ch', 'che', 'switzerland') THEN 'Switzerland'
+    WHEN LOWER(TRIM(country)) IN ('sy', 'syr', 'syrian arab republic', 'syria') THEN 'Syrian Arab Republic'
+    WHEN LOWER(TRIM(country)) IN ('tw', 'twn', 'taiwan, province of china', 'taiwan') THEN 'Taiwan, Province of China'
+    WHEN LOWER(TRIM(country)) IN ('tj', 'tjk', 'tajikistan') THEN 'Tajikistan'
+    WHEN LOWER(TRIM(country)) IN ('tz', 'tza', 'tanzania, united republic of', 'tanzania') THEN 'Tanzania, United Republic of'
+    WHEN LOWER(TRIM(country)) IN ('th', 'tha', 'thailand') THEN 'Thailand'
+    WHEN LOWER(TRIM(country)) IN ('tl', 'tls', 'timor-leste', 'east timor') THEN 'Timor-Leste'
+    WHEN LOWER(TRIM(country)) IN ('tg', 'tgo', 'togo') THEN 'Togo'
+    WHEN LOWER(TRIM(country)) IN ('tk', 'tkl', 'tokelau') THEN 'Tokelau'
+    WHEN LOWER(TRIM(country)) IN ('to', 'ton', 'tonga') THEN 'Tonga'
+    WHEN LOWER(TRIM(country)) IN ('tt', 'tto', 'trinidad and tobago') THEN 'Trinidad and Tobago'
+    WHEN LOWER(TRIM(country)) IN ('tn', 'tun', 'tunisia') THEN 'Tunisia'
+    WHEN LOWER(TRIM(country)) IN ('tr', 'tur', 'türkiye', 'turkey', 'turkiye') THEN 'Türkiye'
+    WHEN LOWER(TRIM(country)) IN ('tm', 'tkm', 'turkmenistan') THEN 'Turkmenistan'
+    WHEN LOWER(TRIM(country)) IN ('tc', 'tca', 'turks and caicos islands') THEN 'Turks and Caicos Islands'
+    WHEN LOWER(TRIM(country)) IN ('tv', 'tuv', 'tuvalu') THEN 'Tuvalu'
+    WHEN LOWER(TRIM(country)) IN ('ug', 'uga', 'uganda') THEN 'Uganda'
+    WHEN LOWER(TRIM(country)) IN ('ua', 'ukr', 'ukraine') THEN 'Ukraine'
+    WHEN LOWER(TRIM(country)) IN ('ae', 'are', 'united arab emirates') THEN 'United Arab Emirates'
+    WHEN LOWER(TRIM(country)) IN ('gb', 'gbr', 'united kingdom', 'uk', 'great britain') THEN 'United Kingdom'
+    WHEN LOWER(TRIM(country)) IN ('us', 'usa', 'united states', 'united states of america') THEN 'United States'
+    WHEN LOWER(TRIM(country)) IN ('um', 'umi', 'united states minor outlying islands') THEN 'United States Minor Outlying Islands'
+    WHEN LOWER(TRIM(country)) IN ('uy', 'ury', 'uruguay') THEN 'Uruguay'
+    WHEN LOWER(TRIM(country)) IN ('uz', 'uzb', 'uzbekistan') THEN 'Uzbekistan'
+    WHEN LOWER(TRIM(country)) IN ('vu', 'vut', 'vanuatu') THEN 'Vanuatu'
+    WHEN LOWER(TRIM(country)) IN ('ve', 'ven', 'venezuela, bolivarian republic of', 'venezuela') THEN 'Venezuela, Bolivarian Republic of'
+    WHEN LOWER(TRIM(country)) IN ('vn', 'vnm', 'viet nam', 'vietnam') THEN 'Viet Nam'
+    WHEN LOWER(TRIM(country)) IN ('vg', 'vgb', 'virgin islands, british', 'british virgin islands') THEN 'Virgin Islands, British'
+    WHEN LOWER(TRIM(country)) IN ('vi', 'vir', 'virgin islands, u.s.', 'us virgin islands') THEN 'Virgin Islands, U.S.'
+    WHEN LOWER(TRIM(country)) IN ('wf', 'wlf', 'wallis and futuna') THEN 'Wallis and Futuna'
+    WHEN LOWER(TRIM(country)) IN ('eh', 'esh', 'western sahara') THEN 'Western Sahara'
+    WHEN LOWER(TRIM(country)) IN ('ye', 'yem', 'yemen') THEN 'Yemen'
+    WHEN LOWER(TRIM(country)) IN ('zm', 'zmb', 'zambia') THEN 'Zambia'
+    WHEN LOWER(TRIM(country)) IN ('zw', 'zwe', 'zimbabwe') THEN 'Zimbabwe'
+    ELSE country
+END
+WHERE country <> '';
+
+-- The live beers normalised to the key of an older live beer are soft deleted, they can be restored once renamed
+UPDATE beers SET deleted_at = CURRENT_TIMESTAMP WHERE id IN (
+    SELECT n.id FROM beers_normalised n JOIN beers_normalised k
+        ON k.name = n.name AND k.brewery = n.brewery AND k.country = n.country AND k.id < n.id
+    WHERE n.deleted_at IS NULL AND k.deleted_at IS NULL
+);
+UPDATE beers SET
+    country = (SELECT n.country FROM beers_normalised n WHERE n.id = beers.id),
+    currency = (SELECT n.currency FROM beers_normalised n WHERE n.id = beers.id);
+DROP TABLE beers_normalised;
@@ -0,0 +1,2 @@
+DROP INDEX idx_exchange_rates_snapshot ON exchange_rates;
+CREATE INDEX idx_exchange_rates_snapshot ON exchange_rates (rated_at, base);
@@ -0,0 +1,6 @@
+-- The rates saved twice by concurrent instances are removed, keeping the first one
+DELETE FROM exchange_rates WHERE id NOT IN (
+    SELECT id FROM (SELECT MIN(id) AS id FROM exchange_rates GROUP BY rated_at, base, currency) kept
+);
+DROP INDEX idx_exchange_rates_snapshot ON exchange_rates;
+CREATE UNIQUE INDEX idx_exchange_rates_snapshot ON exchange_rates (rated_at, base, currency);
@@ -0,0 +1 @@
+ALTER TABLE exchange_rates MODIFY rate DOUBLE NOT NULL;
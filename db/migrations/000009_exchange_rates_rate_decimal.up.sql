@@ -0,0 +1 @@
+ALTER TABLE exchange_rates MODIFY rate DECIMAL(24,12) NOT NULL;
@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations of the MySQL schema, so the binary can run them from anywhere.
+package migrations
+
+import "embed"
+
+// FS holds the NNNNNN_name.up.sql and NNNNNN_name.down.sql files of every schema version.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"github.com/rgraterol/beers-api/pkg/health"
+	"github.com/rgraterol/beers-api/pkg/metrics"
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/restclient"
+	"github.com/rgraterol/beers-api/pkg/tracing"
+	"github.com/rgraterol/beers-api/pkg/usecases/beers"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
+)
+
+// App owns what the API is built from. The initializers create it once and hand it to the router and the
+// services, so nothing is read from package globals and several apps can live in one process.
+type App struct {
+	Config *Config
+	Logger *zap.Logger
+	// Metrics registers the metrics of the app, served on /metrics.
+	Metrics *metrics.Registry
+	// Tracer exports the sampled spans of the app, nil when tracing is disabled.
+	Tracer *tracing.Provider
+	DB     *gorm.DB
+	// Beers keeps the beers catalog, on DB or in memory on the demo mode.
+	Beers beers.BeerRepository
+	// HTTP calls the upstream APIs.
+	HTTP *restclient.Client
+	// Rates is the exchange rates provider chosen on the currency config.
+	Rates rates.Interface
+	// RatesHistory is the store of the rates snapshots, the same one the snapshots and the database fallback use.
+	RatesHistory rates.HistoryInterface
+	// Liveness checks tell if the app must be restarted.
+	Liveness *health.Checker
+	// Readiness checks tell if the app can take traffic, it goes down while the server is draining.
+	Readiness *health.Checker
+	// AdminToken grants admin only operations such as purging beers, an empty token disables them.
+	AdminToken string
+	// ProblemDetails answers every error as RFC 7807 application/problem+json, otherwise only the requests
+	// accepting it get them.
+	ProblemDetails bool
+	// Rounding is how the prices are rounded to the minor units of their currency.
+	Rounding money.RoundingMode
+
+	closers []func() error
+}
+
+// Context carries the logger and the tracer of a on parent, the handlers and jobs of the app log and trace with
+// them.
+func (a *App) Context(parent context.Context) context.Context {
+	return tracing.ContextWithProvider(tracing.ContextWithLogger(parent, a.Logger), a.Tracer)
+}
+
+// OnClose registers fn to release a resource of the app, Close runs them in the reverse order.
+func (a *App) OnClose(fn func() error) {
+	a.closers = append(a.closers, fn)
+}
+
+// Close releases every resource registered, all of them are released even when some fail and the first error is
+// answered.
+func (a *App) Close() error {
+	var err error
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		if closeErr := a.closers[i](); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	a.closers = nil
+	return err
+}
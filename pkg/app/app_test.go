@@ -0,0 +1,66 @@
+package app_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rgraterol/beers-api/pkg/app"
+	"github.com/stretchr/testify/assert"
+)
+
+const configMock = `
+server:
+  port: 8080
+database:
+  host: localhost
+`
+
+func TestCloseRunsClosersInReverseOrder(t *testing.T) {
+	// Given
+	var closed []string
+	a := app.App{}
+	a.OnClose(func() error {
+		closed = append(closed, "db")
+		return errors.New("db already closed")
+	})
+	a.OnClose(func() error {
+		closed = append(closed, "metrics")
+		return errors.New("metrics already closed")
+	})
+	a.OnClose(func() error {
+		closed = append(closed, "snapshotter")
+		return nil
+	})
+	// When
+	err := a.Close()
+	// Then
+	assert.Equal(t, []string{"snapshotter", "metrics", "db"}, closed)
+	assert.EqualError(t, err, "metrics already closed")
+	assert.Nil(t, a.Close())
+}
+
+func TestConfigSection(t *testing.T) {
+	// Given
+	c, err := app.NewConfig("test", []byte(configMock))
+	assert.Nil(t, err)
+	var server struct {
+		Port int `yaml:"port"`
+	}
+	// When
+	err = c.Section("server", &server)
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "test", c.Env)
+	assert.Equal(t, 8080, server.Port)
+}
+
+func TestConfigSectionNotFound(t *testing.T) {
+	// Given
+	c, err := app.NewConfig("test", []byte(configMock))
+	assert.Nil(t, err)
+	var section struct{}
+	// When
+	err = c.Section("currency", &section)
+	// Then
+	assert.EqualError(t, err, `config section "currency" not found`)
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the config file of an environment, read by section.
+type Config struct {
+	Env      string
+	sections map[string][]byte
+}
+
+// NewConfig splits the YAML content of the env config file in its sections.
+func NewConfig(env string, content []byte) (*Config, error) {
+	data := make(map[string]interface{})
+	if err := yaml.Unmarshal(content, data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the config. Err: %w", err)
+	}
+	c := Config{Env: env, sections: make(map[string][]byte)}
+	for section, config := range data {
+		bytes, err := yaml.Marshal(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the config section %s. Err: %w", section, err)
+		}
+		c.sections[section] = bytes
+	}
+	return &c, nil
+}
+
+// Section decodes the section into pointer.
+func (c *Config) Section(section string, pointer interface{}) error {
+	bytes, found := c.sections[section]
+	if !found {
+		return fmt.Errorf(`config section "%s" not found`, section)
+	}
+	if err := yaml.Unmarshal(bytes, pointer); err != nil {
+		return fmt.Errorf("failed to load the config section. Err: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// AdminTokenHeader is the header where admin requests carry their token.
+const AdminTokenHeader = "X-Admin-Token"
+
+// IsAdmin reports whether the request carries adminToken, the shared secret granting admin operations. An empty
+// adminToken disables them.
+func IsAdmin(r *http.Request, adminToken string) bool {
+	token := r.Header.Get(AdminTokenHeader)
+	if adminToken == "" || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1
+}
@@ -1,7 +0,0 @@
-package db
-
-import (
-	"gorm.io/gorm"
-)
-
-var Gorm *gorm.DB
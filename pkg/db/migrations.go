@@ -0,0 +1,351 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gorm.io/gorm"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/pkg/tracing"
+)
+
+const (
+	migrationsTable = "schema_migrations"
+	// migrationsLock names the MySQL advisory lock held while migrating.
+	migrationsLock            = "beers-api:schema_migrations"
+	defaultMigrationsLockTime = time.Minute
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// UnversionedSchemaError is answered when the DB has tables but no version, like the ones created by GORM before
+// the migrations were run. Force the version they match before migrating.
+var UnversionedSchemaError = errors.New("the DB has tables but no schema version, force the version they match")
+
+// Migration is a version of the schema, with the SQL applying it and the SQL reverting it.
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is the version of the schema, and the migrations still pending after it.
+type MigrationStatus struct {
+	Version uint64
+	// Dirty tells the migration to Version failed halfway, the schema must be fixed and the version forced.
+	Dirty   bool
+	Latest  uint64
+	Pending []Migration
+}
+
+// Migrator runs the migrations of Source on DB, keeping the version on the schema_migrations table. Migrators of
+// concurrent pods take turns on a MySQL advisory lock.
+type Migrator struct {
+	DB     *gorm.DB
+	Source fs.FS
+	// LockTimeout is the time to wait for another migrator holding the lock.
+	LockTimeout time.Duration
+}
+
+func NewMigrator(g *gorm.DB, source fs.FS) *Migrator {
+	return &Migrator{DB: g, Source: source, LockTimeout: defaultMigrationsLockTime}
+}
+
+// LoadMigrations reads the NNNNNN_name.up.sql and NNNNNN_name.down.sql files of source, sorted by version.
+func LoadMigrations(source fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(source, "*.sql")
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read the migrations")
+	}
+	byVersion := make(map[uint64]*Migration)
+	for _, file := range files {
+		match := migrationFileName.FindStringSubmatch(path.Base(file))
+		if match == nil {
+			return nil, errors.New("invalid migration name " + file)
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, errors.New("invalid migration name " + file)
+		}
+		content, err := fs.ReadFile(source, file)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read the migration "+file)
+		}
+		migration, found := byVersion[version]
+		if !found {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("the migration %d has two names, %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("the migration %d has no up file", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Status answers the version of the schema, it is 0 before the first migration.
+func (m *Migrator) Status(ctx context.Context) (*MigrationStatus, error) {
+	migrations, err := LoadMigrations(m.Source)
+	if err != nil {
+		return nil, err
+	}
+	var status MigrationStatus
+	if m.DB.WithContext(ctx).Migrator().HasTable(migrationsTable) {
+		status.Version, status.Dirty, err = schemaVersion(m.DB.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, migration := range migrations {
+		status.Latest = migration.Version
+		if migration.Version > status.Version {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return &status, nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.migrate(ctx, func(_ uint64, migrations []Migration) (uint64, error) {
+		if len(migrations) == 0 {
+			return 0, nil
+		}
+		return migrations[len(migrations)-1].Version, nil
+	})
+}
+
+// Down reverts the last steps migrations, or every one when there are fewer.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.migrate(ctx, func(current uint64, migrations []Migration) (uint64, error) {
+		i := sort.Search(len(migrations), func(i int) bool {
+			return migrations[i].Version > current
+		})
+		if i-steps <= 0 {
+			return 0, nil
+		}
+		return migrations[i-steps-1].Version, nil
+	})
+}
+
+// To applies or reverts the migrations needed to reach version, 0 reverts them all.
+func (m *Migrator) To(ctx context.Context, version uint64) error {
+	return m.migrate(ctx, func(uint64, []Migration) (uint64, error) {
+		return version, nil
+	})
+}
+
+// Force sets the version without running any migration. It versions a DB created without them, or cleans a dirty
+// one once the failed migration was fixed by hand.
+func (m *Migrator) Force(ctx context.Context, version uint64) error {
+	return m.withLock(ctx, func(conn *gorm.DB) error {
+		if err := createMigrationsTable(conn); err != nil {
+			return err
+		}
+		return setSchemaVersion(conn, version, false)
+	})
+}
+
+// migrate moves the schema to the version answered by target, which is given the current version and every
+// migration known.
+func (m *Migrator) migrate(ctx context.Context, target func(current uint64, migrations []Migration) (uint64, error)) error {
+	migrations, err := LoadMigrations(m.Source)
+	if err != nil {
+		return err
+	}
+	return m.withLock(ctx, func(conn *gorm.DB) error {
+		current, err := m.prepare(conn)
+		if err != nil {
+			return err
+		}
+		version, err := target(current, migrations)
+		if err != nil {
+			return err
+		}
+		if err = checkKnown(migrations, current); err != nil {
+			return err
+		}
+		if err = checkKnown(migrations, version); err != nil {
+			return err
+		}
+		for _, migration := range migrations {
+			if migration.Version > current && migration.Version <= version {
+				if err = apply(conn, migration.Version, migration.Name, migration.Up); err != nil {
+					return err
+				}
+			}
+		}
+		for i := len(migrations) - 1; i >= 0; i-- {
+			migration := migrations[i]
+			if migration.Version > version && migration.Version <= current {
+				if migration.Down == "" {
+					return fmt.Errorf("the migration %d has no down file", migration.Version)
+				}
+				var previous uint64
+				if i > 0 {
+					previous = migrations[i-1].Version
+				}
+				if err = apply(conn, previous, migration.Name, migration.Down); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// prepare creates the schema_migrations table on a DB without tables and answers its version, it must be clean.
+func (m *Migrator) prepare(conn *gorm.DB) (uint64, error) {
+	if !conn.Migrator().HasTable(migrationsTable) {
+		tables, err := conn.Migrator().GetTables()
+		if err != nil {
+			return 0, errors.Wrap(err, "cannot list the tables")
+		}
+		for _, table := range tables {
+			// SQLite keeps its own tables
+			if !strings.HasPrefix(table, "sqlite_") {
+				return 0, UnversionedSchemaError
+			}
+		}
+		if err = createMigrationsTable(conn); err != nil {
+			return 0, err
+		}
+	}
+	version, dirty, err := schemaVersion(conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("the migration %d failed halfway, fix the schema and force its version", version)
+	}
+	return version, nil
+}
+
+// withLock runs fn on a single connection of the pool holding the migrations lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	return m.DB.WithContext(ctx).Connection(func(tx *gorm.DB) error {
+		// A new session for every statement, the connection is the only thing shared
+		conn := tx.Session(&gorm.Session{})
+		unlock, err := lock(conn, m.LockTimeout)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+		return fn(conn)
+	})
+}
+
+// lock takes the MySQL advisory lock of the migrations on conn. Other DBs, like the SQLite of the tests, are not
+// shared by pods and take no lock.
+func lock(conn *gorm.DB, timeout time.Duration) (func(), error) {
+	if conn.Dialector.Name() != "mysql" {
+		return func() {}, nil
+	}
+	var acquired sql.NullInt64
+	err := conn.Raw("SELECT GET_LOCK(?, ?)", migrationsLock, int(timeout.Seconds())).Scan(&acquired).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot take the migrations lock")
+	}
+	if acquired.Int64 != 1 {
+		return nil, fmt.Errorf("another migration held the lock for more than %s", timeout)
+	}
+	return func() {
+		if err := conn.Exec("SELECT RELEASE_LOCK(?)", migrationsLock).Error; err != nil {
+			tracing.Logger(conn.Statement.Context).Error("cannot release the migrations lock", err)
+		}
+	}, nil
+}
+
+// apply runs the statements of a migration file, leaving the schema dirty at version until all of them succeed.
+// MySQL commits each DDL statement, so a failure cannot be rolled back.
+func apply(conn *gorm.DB, version uint64, name string, content string) error {
+	if err := setSchemaVersion(conn, version, true); err != nil {
+		return err
+	}
+	start := time.Now()
+	for _, statement := range strings.Split(content, ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if err := conn.Exec(statement).Error; err != nil {
+			return errors.Wrapf(err, "the migration %s failed", name)
+		}
+	}
+	if err := setSchemaVersion(conn, version, false); err != nil {
+		return err
+	}
+	tracing.Logger(conn.Statement.Context).Infof("migrated the schema to version %d with %s in %s", version, name,
+		time.Since(start))
+	return nil
+}
+
+func checkKnown(migrations []Migration, version uint64) error {
+	if version == 0 {
+		return nil
+	}
+	for _, migration := range migrations {
+		if migration.Version == version {
+			return nil
+		}
+	}
+	return fmt.Errorf("there is no migration %d", version)
+}
+
+func createMigrationsTable(conn *gorm.DB) error {
+	err := conn.Exec("CREATE TABLE IF NOT EXISTS " + migrationsTable + " (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)").Error
+	if err != nil {
+		return errors.Wrap(err, "cannot create the schema_migrations table")
+	}
+	return nil
+}
+
+// schemaVersion answers the version migrated on the schema_migrations table and if its migration failed halfway.
+func schemaVersion(conn *gorm.DB) (uint64, bool, error) {
+	var row struct {
+		Version uint64
+		Dirty   bool
+	}
+	err := conn.Raw("SELECT version, dirty FROM " + migrationsTable + " LIMIT 1").Scan(&row).Error
+	if err != nil {
+		return 0, false, errors.Wrap(err, "cannot read the schema version")
+	}
+	return row.Version, row.Dirty, nil
+}
+
+// setSchemaVersion keeps a single row on schema_migrations, as golang-migrate does.
+func setSchemaVersion(conn *gorm.DB, version uint64, dirty bool) error {
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM " + migrationsTable).Error; err != nil {
+			return err
+		}
+		return tx.Exec("INSERT INTO "+migrationsTable+" (version, dirty) VALUES (?, ?)", version, dirty).Error
+	})
+	if err != nil {
+		return errors.Wrap(err, "cannot write the schema version")
+	}
+	return nil
+}
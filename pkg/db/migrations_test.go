@@ -0,0 +1,190 @@
+package db_test
+
+import (
+	"context"
+	"errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rgraterol/beers-api/db/migrations"
+	"github.com/rgraterol/beers-api/pkg/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func migrationsMock() fstest.MapFS {
+	return fstest.MapFS{
+		"000001_breweries.up.sql":        {Data: []byte("CREATE TABLE breweries (id INTEGER PRIMARY KEY, name TEXT);\nCREATE INDEX idx_breweries_name ON breweries (name);")},
+		"000001_breweries.down.sql":      {Data: []byte("DROP TABLE breweries;")},
+		"000002_breweries_city.up.sql":   {Data: []byte("ALTER TABLE breweries ADD COLUMN city TEXT;")},
+		"000002_breweries_city.down.sql": {Data: []byte("ALTER TABLE breweries DROP COLUMN city;")},
+		"000003_styles.up.sql":           {Data: []byte("CREATE TABLE styles (id INTEGER PRIMARY KEY);")},
+		"000003_styles.down.sql":         {Data: []byte("DROP TABLE styles;")},
+	}
+}
+
+func TestMigratorUpDownAndStatus(t *testing.T) {
+	// Given
+	g := initMigrationsTestDB(t)
+	m := db.NewMigrator(g, migrationsMock())
+	// When
+	err := m.Up(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assertVersion(t, m, 3, 0)
+	assert.True(t, g.Migrator().HasColumn("breweries", "city"))
+	assert.True(t, g.Migrator().HasTable("styles"))
+
+	assert.Nil(t, m.Down(context.Background(), 2))
+	assertVersion(t, m, 1, 2)
+	assert.False(t, g.Migrator().HasColumn("breweries", "city"))
+	assert.False(t, g.Migrator().HasTable("styles"))
+
+	assert.Nil(t, m.To(context.Background(), 2))
+	assertVersion(t, m, 2, 1)
+	assert.Nil(t, m.To(context.Background(), 0))
+	assertVersion(t, m, 0, 3)
+	assert.False(t, g.Migrator().HasTable("breweries"))
+	assert.EqualError(t, m.To(context.Background(), 7), "there is no migration 7")
+}
+
+func TestMigratorDirtyAfterFailure(t *testing.T) {
+	// Given
+	g := initMigrationsTestDB(t)
+	source := migrationsMock()
+	source["000002_breweries_city.up.sql"] = &fstest.MapFile{Data: []byte("ALTER TABLE breweries ADD COLUMN city TEXT;\nALTER TABLE missing ADD COLUMN city TEXT;")}
+	m := db.NewMigrator(g, source)
+	// When
+	err := m.Up(context.Background())
+	// Then
+	assert.Contains(t, err.Error(), "the migration breweries_city failed")
+	status, err := m.Status(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), status.Version)
+	assert.True(t, status.Dirty)
+	assert.EqualError(t, m.Up(context.Background()), "the migration 2 failed halfway, fix the schema and force its version")
+
+	assert.Nil(t, m.Force(context.Background(), 2))
+	assert.Nil(t, m.Up(context.Background()))
+	assertVersion(t, m, 3, 0)
+}
+
+func TestMigratorUnversionedSchema(t *testing.T) {
+	// Given
+	g := initMigrationsTestDB(t)
+	assert.Nil(t, g.Exec("CREATE TABLE breweries (id INTEGER PRIMARY KEY, name TEXT)").Error)
+	m := db.NewMigrator(g, migrationsMock())
+	// When
+	err := m.Up(context.Background())
+	// Then
+	assert.True(t, errors.Is(err, db.UnversionedSchemaError))
+	assert.Nil(t, m.Force(context.Background(), 1))
+	assert.Nil(t, m.Up(context.Background()))
+	assertVersion(t, m, 3, 0)
+}
+
+func TestLoadMigrations(t *testing.T) {
+	// When
+	loaded, err := db.LoadMigrations(migrations.FS)
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, 9, len(loaded))
+	for i, migration := range loaded {
+		assert.Equal(t, uint64(i+1), migration.Version)
+		assert.NotEmpty(t, migration.Up)
+		assert.NotEmpty(t, migration.Down)
+	}
+	assert.Equal(t, "beers_schema", loaded[0].Name)
+}
+
+func TestNormaliseCurrencyCountryMigration(t *testing.T) {
+	// Given
+	g := initMigrationsTestDB(t)
+	m := normaliseMigrator(t, g)
+	assert.Nil(t, m.To(context.Background(), 1))
+	assert.Nil(t, g.Exec("INSERT INTO beers (id, name, brewery, country, currency) VALUES (1, 'Golden', 'Kross', 'chl', 'usd'), "+
+		"(2, 'Bock', 'Kross', ' CL', 'Clp'), (3, 'Stout', 'Kross', 'Chile', 'EUR'), (4, 'Ale', NULL, 'Narnia', 'dollars'), "+
+		"(5, 'Lager', 'Austral', '', 'ars')").Error)
+	// When
+	err := m.Up(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, []normalisedBeer{{"Chile", "USD", false}, {"Chile", "CLP", false}, {"Chile", "EUR", false},
+		{"Narnia", "dollars", false}, {"", "ARS", false}}, normalisedBeers(t, g))
+	assert.False(t, g.Migrator().HasTable("beers_normalised"))
+}
+
+func TestNormaliseCurrencyCountryMigrationDeletesTheDuplicates(t *testing.T) {
+	// Given
+	g := initMigrationsTestDB(t)
+	m := normaliseMigrator(t, g)
+	assert.Nil(t, m.To(context.Background(), 1))
+	assert.Nil(t, g.Exec("INSERT INTO beers (id, name, brewery, country, currency, deleted_at) VALUES "+
+		"(1, 'Golden', 'Kross', 'Chile', 'USD', NULL), (2, 'Golden', 'Kross', 'chl', 'usd', NULL), "+
+		"(3, 'Golden', 'Kross', 'cl', 'USD', '2021-01-01 00:00:00'), (4, 'Golden', NULL, 'chl', 'USD', NULL), "+
+		"(5, 'Golden', NULL, 'Chile', 'USD', NULL)").Error)
+	// When
+	err := m.Up(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, []normalisedBeer{{"Chile", "USD", false}, {"Chile", "USD", true}, {"Chile", "USD", true},
+		{"Chile", "USD", false}, {"Chile", "USD", false}}, normalisedBeers(t, g))
+
+	assert.Nil(t, m.Down(context.Background(), 1))
+	assert.Equal(t, []normalisedBeer{{"Chile", "USD", false}, {"chl", "usd", false}, {"cl", "USD", true},
+		{"chl", "USD", false}, {"Chile", "USD", false}}, normalisedBeers(t, g))
+	assert.False(t, g.Migrator().HasTable("beers_original_spellings"))
+}
+
+type normalisedBeer struct {
+	Country  string
+	Currency string
+	Deleted  bool
+}
+
+// normaliseMigrator runs the normalise migration on a beers table with the unique key of the live beers.
+func normaliseMigrator(t *testing.T, g *gorm.DB) *db.Migrator {
+	up, err := fs.ReadFile(migrations.FS, "000007_beers_normalise_currency_country.up.sql")
+	assert.Nil(t, err)
+	down, err := fs.ReadFile(migrations.FS, "000007_beers_normalise_currency_country.down.sql")
+	assert.Nil(t, err)
+	return db.NewMigrator(g, fstest.MapFS{
+		"000001_beers.up.sql": {Data: []byte("CREATE TABLE beers (id INTEGER PRIMARY KEY, name TEXT, brewery TEXT, " +
+			"country TEXT, currency TEXT, deleted_at TIMESTAMP);\n" +
+			"CREATE UNIQUE INDEX idx_beers_live_key ON beers (name, brewery, country) WHERE deleted_at IS NULL;")},
+		"000001_beers.down.sql":     {Data: []byte("DROP TABLE beers;")},
+		"000002_normalise.up.sql":   {Data: up},
+		"000002_normalise.down.sql": {Data: down},
+	})
+}
+
+func normalisedBeers(t *testing.T, g *gorm.DB) []normalisedBeer {
+	var beers []normalisedBeer
+	assert.Nil(t, g.Raw("SELECT country, currency, deleted_at IS NOT NULL AS deleted FROM beers ORDER BY id").Scan(&beers).Error)
+	return beers
+}
+
+func TestLoadMigrationsInvalid(t *testing.T) {
+	_, err := db.LoadMigrations(fstest.MapFS{"beers.up.sql": {Data: []byte("SELECT 1")}})
+	assert.EqualError(t, err, "invalid migration name beers.up.sql")
+	_, err = db.LoadMigrations(fstest.MapFS{"000001_beers.down.sql": {Data: []byte("SELECT 1")}})
+	assert.EqualError(t, err, "the migration 1 has no up file")
+}
+
+func assertVersion(t *testing.T, m *db.Migrator, version uint64, pending int) {
+	status, err := m.Status(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, version, status.Version)
+	assert.False(t, status.Dirty)
+	assert.Equal(t, uint64(3), status.Latest)
+	assert.Equal(t, pending, len(status.Pending))
+}
+
+// initMigrationsTestDB opens an empty in-memory DB shared by the connections of its pool.
+func initMigrationsTestDB(t *testing.T) *gorm.DB {
+	g, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	assert.Nil(t, err)
+	return g
+}
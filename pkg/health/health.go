@@ -0,0 +1,155 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/responses"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+	StatusDraining = "draining"
+
+	DefaultTimeout = 2 * time.Second
+)
+
+// Check probes a dependency, the failures of Critical checks take the report down while the rest degrade it.
+type Check struct {
+	Name     string
+	Critical bool
+	Run      func(ctx context.Context) error
+}
+
+// Result is the outcome of a Check, Latency is in milliseconds.
+type Result struct {
+	Name     string  `json:"name"`
+	Status   string  `json:"status"`
+	Critical bool    `json:"critical"`
+	Latency  float64 `json:"latency_ms"`
+	Error    string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Checker runs its checks concurrently, each one within Timeout.
+type Checker struct {
+	Timeout time.Duration
+
+	mu       sync.RWMutex
+	checks   []Check
+	draining int32
+}
+
+// Register adds check, replacing the one with the same name.
+func (c *Checker) Register(check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := range c.checks {
+		if c.checks[i].Name == check.Name {
+			c.checks[i] = check
+			return
+		}
+	}
+	c.checks = append(c.checks, check)
+}
+
+// Drain takes the checker down for good, the checks keep being reported.
+func (c *Checker) Drain() {
+	atomic.StoreInt32(&c.draining, 1)
+}
+
+func (c *Checker) Draining() bool {
+	return atomic.LoadInt32(&c.draining) == 1
+}
+
+// Run answers the result of every check in the order they were registered.
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := make([]Check, len(c.checks))
+	copy(checks, c.checks)
+	c.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: results}
+	for _, result := range results {
+		if result.Status == StatusUp {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+	if c.Draining() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := runCheck(ctx, check)
+	result := Result{
+		Name:     check.Name,
+		Status:   StatusUp,
+		Critical: check.Critical,
+		Latency:  float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status, result.Error = StatusDown, err.Error()
+	}
+	return result
+}
+
+// runCheck gives up on checks ignoring their context once it is done.
+func runCheck(ctx context.Context, check Check) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check.Run(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Handler answers the report of c, with a 503 when it is down or draining.
+func Handler(c *Checker) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := c.Run(r.Context())
+		if report.Status == StatusDown || report.Status == StatusDraining {
+			responses.Unavailable(w, report)
+			return
+		}
+		responses.OK(w, report)
+	}
+}
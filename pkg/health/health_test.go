@@ -0,0 +1,128 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func up(ctx context.Context) error {
+	return nil
+}
+
+func down(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestRunUp(t *testing.T) {
+	// Given
+	c := health.Checker{}
+	c.Register(health.Check{Name: "database", Critical: true, Run: up})
+	// When
+	report := c.Run(context.Background())
+	// Then
+	assert.Equal(t, health.StatusUp, report.Status)
+	assert.Len(t, report.Checks, 1)
+	assert.Equal(t, "database", report.Checks[0].Name)
+	assert.Equal(t, health.StatusUp, report.Checks[0].Status)
+	assert.True(t, report.Checks[0].Critical)
+}
+
+func TestRunDegradedByNonCriticalCheck(t *testing.T) {
+	// Given
+	c := health.Checker{}
+	c.Register(health.Check{Name: "database", Critical: true, Run: up})
+	c.Register(health.Check{Name: "rates_cache", Run: down})
+	// When
+	report := c.Run(context.Background())
+	// Then
+	assert.Equal(t, health.StatusDegraded, report.Status)
+	assert.Equal(t, health.StatusDown, report.Checks[1].Status)
+	assert.Equal(t, "connection refused", report.Checks[1].Error)
+}
+
+func TestRunDownByCriticalCheck(t *testing.T) {
+	// Given
+	c := health.Checker{}
+	c.Register(health.Check{Name: "rates_cache", Run: down})
+	c.Register(health.Check{Name: "database", Critical: true, Run: down})
+	// When
+	report := c.Run(context.Background())
+	// Then
+	assert.Equal(t, health.StatusDown, report.Status)
+}
+
+func TestRunTimeout(t *testing.T) {
+	// Given
+	c := health.Checker{Timeout: 10 * time.Millisecond}
+	c.Register(health.Check{Name: "stuck", Critical: true, Run: func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}})
+	// When
+	start := time.Now()
+	report := c.Run(context.Background())
+	// Then
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+	assert.Equal(t, health.StatusDown, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks[0].Error)
+}
+
+func TestRegisterReplacesByName(t *testing.T) {
+	// Given
+	c := health.Checker{}
+	c.Register(health.Check{Name: "database", Critical: true, Run: down})
+	// When
+	c.Register(health.Check{Name: "database", Critical: true, Run: up})
+	// Then
+	assert.Equal(t, health.StatusUp, c.Run(context.Background()).Status)
+}
+
+func TestHandlerDraining503(t *testing.T) {
+	//GIVEN
+	c := &health.Checker{}
+	c.Register(health.Check{Name: "database", Critical: true, Run: up})
+	c.Drain()
+	w := httptest.NewRecorder()
+	//WHEN
+	health.Handler(c)(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	var report health.Report
+	err := json.NewDecoder(w.Body).Decode(&report)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, health.StatusDraining, report.Status)
+	assert.Equal(t, health.StatusUp, report.Checks[0].Status)
+}
+
+func TestHandlerDegraded200(t *testing.T) {
+	//GIVEN
+	c := &health.Checker{}
+	c.Register(health.Check{Name: "rates_cache", Run: down})
+	w := httptest.NewRecorder()
+	//WHEN
+	health.Handler(c)(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	var report health.Report
+	err := json.NewDecoder(w.Body).Decode(&report)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, health.StatusDegraded, report.Status)
+}
+
+func TestHandlerNoChecks200(t *testing.T) {
+	//GIVEN
+	w := httptest.NewRecorder()
+	//WHEN
+	health.Handler(&health.Checker{})(w, httptest.NewRequest(http.MethodGet, "/health/live", nil))
+	//THEN
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"up","checks":[]}`, w.Body.String())
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+const gormStartKey = "metrics:start"
+
+// GormPlugin measures every create, query, update, delete, row and raw operation of the GORM DB using it on
+// Registry.
+type GormPlugin struct {
+	Registry *Registry
+}
+
+func (GormPlugin) Name() string {
+	return "metrics"
+}
+
+func (p GormPlugin) Initialize(db *gorm.DB) error {
+	m := &gormMetrics{
+		duration: NewHistogramVec(p.Registry, "db_query_duration_seconds",
+			"Duration of the GORM operations by operation and table.", nil, "operation", "table"),
+		errors: NewCounterVec(p.Registry, "db_query_errors_total",
+			"Failed GORM operations by operation and table, not found records aside.", "operation", "table"),
+	}
+	cb := db.Callback()
+	registrations := []func() error{
+		func() error { return cb.Create().Before("gorm:create").Register("metrics:before_create", gormBefore) },
+		func() error {
+			return cb.Create().After("gorm:create").Register("metrics:after_create", m.after("create"))
+		},
+		func() error { return cb.Query().Before("gorm:query").Register("metrics:before_query", gormBefore) },
+		func() error { return cb.Query().After("gorm:query").Register("metrics:after_query", m.after("query")) },
+		func() error { return cb.Update().Before("gorm:update").Register("metrics:before_update", gormBefore) },
+		func() error {
+			return cb.Update().After("gorm:update").Register("metrics:after_update", m.after("update"))
+		},
+		func() error { return cb.Delete().Before("gorm:delete").Register("metrics:before_delete", gormBefore) },
+		func() error {
+			return cb.Delete().After("gorm:delete").Register("metrics:after_delete", m.after("delete"))
+		},
+		func() error { return cb.Row().Before("gorm:row").Register("metrics:before_row", gormBefore) },
+		func() error { return cb.Row().After("gorm:row").Register("metrics:after_row", m.after("row")) },
+		func() error { return cb.Raw().Before("gorm:raw").Register("metrics:before_raw", gormBefore) },
+		func() error { return cb.Raw().After("gorm:raw").Register("metrics:after_raw", m.after("raw")) },
+	}
+	for _, register := range registrations {
+		if err := register(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func gormBefore(db *gorm.DB) {
+	db.InstanceSet(gormStartKey, time.Now())
+}
+
+type gormMetrics struct {
+	duration *HistogramVec
+	errors   *CounterVec
+}
+
+func (m *gormMetrics) after(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(gormStartKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		m.duration.Observe(time.Since(start).Seconds(), operation, table)
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			m.errors.Inc(operation, table)
+		}
+	}
+}
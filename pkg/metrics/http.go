@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// unmatchedRoute labels the requests no route matched, so unknown paths cannot blow up the series.
+const unmatchedRoute = "unmatched"
+
+// Middleware measures the requests by their chi route pattern on r, it must be used on the chi router.
+func Middleware(r *Registry) func(next http.Handler) http.Handler {
+	requests := NewCounterVec(r, "http_requests_total",
+		"HTTP requests served by method, chi route pattern and status.", "method", "route", "status")
+	duration := NewHistogramVec(r, "http_request_duration_seconds",
+		"Latency of the HTTP requests by method and chi route pattern.", nil, "method", "route")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			requests.Inc(r.Method, route, strconv.Itoa(status))
+			duration.Observe(time.Since(start).Seconds(), r.Method, route)
+		})
+	}
+}
@@ -0,0 +1,277 @@
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the latency buckets in seconds, from 1ms to 10s.
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector writes the samples of a metric family.
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry holds metric families, written sorted by name. Each app owns one, so the apps of a process never mix
+// their metrics.
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// register adds c, panicking when its name is taken as it is a programming error.
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.collectors == nil {
+		r.collectors = make(map[string]collector)
+	}
+	if _, ok := r.collectors[c.name()]; ok {
+		panic("metric " + c.name() + " is already registered")
+	}
+	r.collectors[c.name()] = c
+}
+
+// WriteTo writes every metric family in the text exposition format.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	collectors := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		collectors = append(collectors, c)
+	}
+	r.mu.Unlock()
+	sort.Slice(collectors, func(i, j int) bool {
+		return collectors[i].name() < collectors[j].name()
+	})
+	var buf bytes.Buffer
+	for _, c := range collectors {
+		c.write(&buf)
+	}
+	return buf.WriteTo(w)
+}
+
+// HandlerFor serves the metric families of every registry, one after the other.
+func HandlerFor(registries ...*Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		for _, r := range registries {
+			r.WriteTo(w)
+		}
+	}
+}
+
+// family is the name, help and label names shared by the metrics of a vector.
+type family struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func (f *family) name() string {
+	return f.metricName
+}
+
+func (f *family) header(w io.Writer, kind string) {
+	io.WriteString(w, "# HELP "+f.metricName+" "+escapeHelp(f.help)+"\n")
+	io.WriteString(w, "# TYPE "+f.metricName+" "+kind+"\n")
+}
+
+// key joins label values, the separator cannot be on valid UTF-8 text.
+func (f *family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic("metric " + f.metricName + " needs " + strconv.Itoa(len(f.labels)) + " label values")
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs writes the labels of values plus the extra pair when its name isn't empty.
+func (f *family) labelPairs(values []string, extraName string, extraValue string) string {
+	var pairs []string
+	for i, label := range f.labels {
+		pairs = append(pairs, label+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+escapeLabel(extraValue)+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// CounterVec counts events by label values.
+type CounterVec struct {
+	family
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+func NewCounterVec(r *Registry, name string, help string, labels ...string) *CounterVec {
+	c := &CounterVec{family: family{metricName: name, help: help, labels: labels}, values: make(map[string]*counterValue)}
+	r.register(c)
+	return c
+}
+
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add increases the counter of values by v, which cannot be negative.
+func (c *CounterVec) Add(v float64, values ...string) {
+	if v < 0 {
+		panic("counter " + c.metricName + " cannot decrease")
+	}
+	key := c.key(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cv, ok := c.values[key]
+	if !ok {
+		cv = &counterValue{labels: append([]string(nil), values...)}
+		c.values[key] = cv
+	}
+	cv.value += v
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w, "counter")
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		cv := c.values[key]
+		io.WriteString(w, c.metricName+c.labelPairs(cv.labels, "", "")+" "+formatFloat(cv.value)+"\n")
+	}
+}
+
+// HistogramVec samples observations by label values into cumulative buckets.
+type HistogramVec struct {
+	family
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec uses DefaultBuckets when buckets is empty.
+func NewHistogramVec(r *Registry, name string, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &HistogramVec{
+		family:  family{metricName: name, help: help, labels: labels},
+		buckets: buckets,
+		values:  make(map[string]*histogramValue),
+	}
+	r.register(h)
+	return h
+}
+
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	key := h.key(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hv, ok := h.values[key]
+	if !ok {
+		hv = &histogramValue{labels: append([]string(nil), values...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hv
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			hv.counts[i]++
+		}
+	}
+	hv.count++
+	hv.sum += v
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w, "histogram")
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		hv := h.values[key]
+		for i, upper := range h.buckets {
+			io.WriteString(w, h.metricName+"_bucket"+h.labelPairs(hv.labels, "le", formatFloat(upper))+" "+
+				strconv.FormatUint(hv.counts[i], 10)+"\n")
+		}
+		io.WriteString(w, h.metricName+"_bucket"+h.labelPairs(hv.labels, "le", "+Inf")+" "+
+			strconv.FormatUint(hv.count, 10)+"\n")
+		io.WriteString(w, h.metricName+"_sum"+h.labelPairs(hv.labels, "", "")+" "+formatFloat(hv.sum)+"\n")
+		io.WriteString(w, h.metricName+"_count"+h.labelPairs(hv.labels, "", "")+" "+
+			strconv.FormatUint(hv.count, 10)+"\n")
+	}
+}
+
+// funcMetric reads its value when it is scraped, for the counters and gauges kept elsewhere.
+type funcMetric struct {
+	family
+	kind string
+	fn   func() float64
+}
+
+// NewCounterFunc exposes a counter kept by fn, such as the hits of a cache.
+func NewCounterFunc(r *Registry, name string, help string, fn func() float64) {
+	r.register(&funcMetric{family: family{metricName: name, help: help}, kind: "counter", fn: fn})
+}
+
+func NewGaugeFunc(r *Registry, name string, help string, fn func() float64) {
+	r.register(&funcMetric{family: family{metricName: name, help: help}, kind: "gauge", fn: fn})
+}
+
+func (f *funcMetric) write(w io.Writer) {
+	f.header(w, f.kind)
+	io.WriteString(w, f.metricName+" "+formatFloat(f.fn())+"\n")
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,135 @@
+package metrics_test
+
+import (
+	"bytes"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rgraterol/beers-api/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func exposition(r *metrics.Registry) string {
+	var buf bytes.Buffer
+	r.WriteTo(&buf)
+	return buf.String()
+}
+
+func TestCounterVecExposition(t *testing.T) {
+	// Given
+	r := &metrics.Registry{}
+	c := metrics.NewCounterVec(r, "beers_total", "Beers \"served\".", "country")
+	// When
+	c.Inc("Chile")
+	c.Add(2, "Chile")
+	c.Inc(`Côte "d'Ivoire"`)
+	// Then
+	assert.Equal(t, "# HELP beers_total Beers \"served\".\n"+
+		"# TYPE beers_total counter\n"+
+		"beers_total{country=\"Chile\"} 3\n"+
+		"beers_total{country=\"Côte \\\"d'Ivoire\\\"\"} 1\n", exposition(r))
+}
+
+func TestHistogramVecExposition(t *testing.T) {
+	// Given
+	r := &metrics.Registry{}
+	h := metrics.NewHistogramVec(r, "latency_seconds", "Latency.", []float64{1, 0.1})
+	// When
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(3)
+	// Then
+	assert.Equal(t, "# HELP latency_seconds Latency.\n"+
+		"# TYPE latency_seconds histogram\n"+
+		"latency_seconds_bucket{le=\"0.1\"} 1\n"+
+		"latency_seconds_bucket{le=\"1\"} 2\n"+
+		"latency_seconds_bucket{le=\"+Inf\"} 3\n"+
+		"latency_seconds_sum 3.55\n"+
+		"latency_seconds_count 3\n", exposition(r))
+}
+
+func TestFuncMetricsSortedByName(t *testing.T) {
+	// Given
+	r := &metrics.Registry{}
+	metrics.NewGaugeFunc(r, "b_age_seconds", "Age.", func() float64 { return 1.5 })
+	metrics.NewCounterFunc(r, "a_hits_total", "Hits.", func() float64 { return 7 })
+	// When
+	out := exposition(r)
+	// Then
+	assert.Equal(t, "# HELP a_hits_total Hits.\n# TYPE a_hits_total counter\na_hits_total 7\n"+
+		"# HELP b_age_seconds Age.\n# TYPE b_age_seconds gauge\nb_age_seconds 1.5\n", out)
+}
+
+func TestRegisterTwicePanics(t *testing.T) {
+	// Given
+	r := &metrics.Registry{}
+	metrics.NewCounterVec(r, "beers_total", "Beers.")
+	// When
+	// Then
+	assert.Panics(t, func() {
+		metrics.NewCounterVec(r, "beers_total", "Beers.")
+	})
+}
+
+func TestHandlerForEveryRegistry(t *testing.T) {
+	// Given
+	first, second := &metrics.Registry{}, &metrics.Registry{}
+	metrics.NewCounterFunc(first, "a_hits_total", "Hits.", func() float64 { return 7 })
+	metrics.NewGaugeFunc(second, "b_age_seconds", "Age.", func() float64 { return 1.5 })
+	w := httptest.NewRecorder()
+	// When
+	metrics.HandlerFor(first, second)(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	// Then
+	assert.Equal(t, metrics.ContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "# HELP a_hits_total Hits.\n# TYPE a_hits_total counter\na_hits_total 7\n"+
+		"# HELP b_age_seconds Age.\n# TYPE b_age_seconds gauge\nb_age_seconds 1.5\n", w.Body.String())
+}
+
+func TestMiddlewareByRoutePattern(t *testing.T) {
+	// Given
+	registry := &metrics.Registry{}
+	r := chi.NewRouter()
+	r.Use(metrics.Middleware(registry))
+	r.Get("/beers/{beerID}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	// When
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/beers/42", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown/path", nil))
+	w := httptest.NewRecorder()
+	metrics.HandlerFor(registry)(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	// Then
+	assert.Equal(t, metrics.ContentType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `http_requests_total{method="GET",route="/beers/{beerID}",status="404"} 1`)
+	assert.Contains(t, w.Body.String(), `http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, w.Body.String(), `http_request_duration_seconds_count{method="GET",route="/beers/{beerID}"} 1`)
+}
+
+func TestGormPlugin(t *testing.T) {
+	// Given
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	assert.Nil(t, err)
+	registry := &metrics.Registry{}
+	assert.Nil(t, db.Use(metrics.GormPlugin{Registry: registry}))
+	type brewery struct {
+		ID   int64
+		Name string
+	}
+	assert.Nil(t, db.AutoMigrate(&brewery{}))
+	// When
+	assert.Nil(t, db.Create(&brewery{Name: "Kunstmann"}).Error)
+	var found brewery
+	assert.NotNil(t, db.Where("name = ?", "Austral").First(&found).Error)
+	assert.NotNil(t, db.Table("taps").Find(&found).Error)
+	w := httptest.NewRecorder()
+	metrics.HandlerFor(registry)(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	// Then
+	assert.Contains(t, w.Body.String(), `db_query_duration_seconds_count{operation="create",table="breweries"} 1`)
+	assert.Contains(t, w.Body.String(), `db_query_duration_seconds_count{operation="query",table="breweries"} 1`)
+	assert.NotContains(t, w.Body.String(), `db_query_errors_total{operation="query",table="breweries"}`)
+	assert.Contains(t, w.Body.String(), `db_query_errors_total{operation="query",table="taps"} 1`)
+}
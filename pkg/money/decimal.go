@@ -0,0 +1,167 @@
+package money
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// Decimal is a fixed point number. It is stored as DECIMAL and serialised as a JSON number keeping its exact
+// digits, so no precision is lost on the way.
+type Decimal struct {
+	d decimal.Decimal
+}
+
+var Zero = Decimal{}
+
+// Scale is the amount of decimal places the prices are stored with, longer ones must be refused or rounded first.
+const Scale = 6
+
+func NewFromInt(value int64) Decimal {
+	return Decimal{decimal.NewFromInt(value)}
+}
+
+// NewFromFloat keeps the shortest decimal representation of f, 0.1 stays 0.1.
+func NewFromFloat(f float64) Decimal {
+	return Decimal{decimal.NewFromFloat(f)}
+}
+
+// RequireFromString is NewFromString for literals known to be valid, it panics otherwise.
+func RequireFromString(s string) Decimal {
+	d, err := NewFromString(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func NewFromString(s string) (Decimal, error) {
+	d, err := decimal.NewFromString(strings.TrimSpace(s))
+	if err != nil {
+		return Zero, errors.Wrap(err, "invalid decimal "+s)
+	}
+	return Decimal{d}, nil
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	return Decimal{d.d.Add(other.d)}
+}
+
+func (d Decimal) Sub(other Decimal) Decimal {
+	return Decimal{d.d.Sub(other.d)}
+}
+
+func (d Decimal) Mul(other Decimal) Decimal {
+	return Decimal{d.d.Mul(other.d)}
+}
+
+// Div keeps 16 decimal places, round the result to the precision needed.
+func (d Decimal) Div(other Decimal) Decimal {
+	return Decimal{d.d.Div(other.d)}
+}
+
+func (d Decimal) Cmp(other Decimal) int {
+	return d.d.Cmp(other.d)
+}
+
+// Equal compares the values, 1.50 equals 1.5.
+func (d Decimal) Equal(other Decimal) bool {
+	return d.d.Equal(other.d)
+}
+
+func (d Decimal) Sign() int {
+	return d.d.Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.d.IsZero()
+}
+
+// Round rounds to places decimal places with mode.
+func (d Decimal) Round(places int32, mode RoundingMode) Decimal {
+	switch mode {
+	case HalfEven:
+		return Decimal{d.d.RoundBank(places)}
+	case Up:
+		return Decimal{d.d.RoundUp(places)}
+	case Down:
+		return Decimal{d.d.RoundDown(places)}
+	case Ceiling:
+		return Decimal{d.d.RoundCeil(places)}
+	case Floor:
+		return Decimal{d.d.RoundFloor(places)}
+	}
+	return Decimal{d.d.Round(places)}
+}
+
+// Places is the amount of decimal places of d, trailing zeros left out: 1.50 has 1.
+func (d Decimal) Places() int32 {
+	s := d.d.String()
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		return int32(len(s) - dot - 1)
+	}
+	return 0
+}
+
+// Float64 is meant for display and ratios only, never for money maths.
+func (d Decimal) Float64() float64 {
+	f, _ := d.d.Float64()
+	return f
+}
+
+// String trims the trailing zeros, 1.50 becomes 1.5.
+func (d Decimal) String() string {
+	return d.d.String()
+}
+
+// StringFixed pads or rounds half up to places decimal places.
+func (d Decimal) StringFixed(places int32) string {
+	return d.d.StringFixed(places)
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.d.String()), nil
+}
+
+// UnmarshalJSON accepts numbers and quoted numbers.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	err := d.d.UnmarshalJSON(data)
+	if err != nil {
+		return errors.Errorf("invalid decimal %s", data)
+	}
+	return nil
+}
+
+// MarshalText lets the decimals be YAML and XML values.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.d.String()), nil
+}
+
+func (d *Decimal) UnmarshalText(text []byte) error {
+	parsed, err := NewFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d *Decimal) Scan(value interface{}) error {
+	return d.d.Scan(value)
+}
+
+func (d Decimal) Value() (driver.Value, error) {
+	return d.d.Value()
+}
+
+// GormDataType is the column type used on migrations, wide enough for every ISO-4217 minor unit. It keeps Scale
+// decimal places.
+func (Decimal) GormDataType() string {
+	return "decimal(20,6)"
+}
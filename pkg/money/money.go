@@ -0,0 +1,82 @@
+package money
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/pkg/refdata"
+)
+
+// RoundingMode tells how amounts are rounded to the minor units of their currency.
+type RoundingMode int
+
+const (
+	// HalfUp rounds half away from zero, 2.5 becomes 3 and -2.5 becomes -3.
+	HalfUp RoundingMode = iota
+	// HalfEven rounds half to the even neighbour, 2.5 becomes 2 and 3.5 becomes 4.
+	HalfEven
+	// Up rounds away from zero.
+	Up
+	// Down truncates towards zero.
+	Down
+	// Ceiling rounds towards positive infinity.
+	Ceiling
+	// Floor rounds towards negative infinity.
+	Floor
+)
+
+var roundingModes = map[string]RoundingMode{
+	"half_up":   HalfUp,
+	"half_even": HalfEven,
+	"up":        Up,
+	"down":      Down,
+	"ceiling":   Ceiling,
+	"floor":     Floor,
+}
+
+func ParseRoundingMode(name string) (RoundingMode, error) {
+	if name == "" {
+		return HalfUp, nil
+	}
+	mode, ok := roundingModes[strings.ToLower(name)]
+	if !ok {
+		return HalfUp, errors.New("unknown rounding mode " + name)
+	}
+	return mode, nil
+}
+
+// defaultMinorUnits applies to the currencies missing on the ISO-4217 registry.
+const defaultMinorUnits = 2
+
+// MinorUnits is the amount of decimal places of currency, such as 2 for USD cents or 0 for CLP.
+func MinorUnits(currency string) int32 {
+	if c, ok := refdata.LookupCurrency(currency); ok {
+		return c.MinorUnits
+	}
+	return defaultMinorUnits
+}
+
+// Money is an amount of a currency.
+type Money struct {
+	Amount   Decimal `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+func New(amount Decimal, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// RoundWith rounds the amount to the minor units of the currency with mode.
+func (m Money) RoundWith(mode RoundingMode) Money {
+	return Money{Amount: m.Amount.Round(MinorUnits(m.Currency), mode), Currency: m.Currency}
+}
+
+// Convert applies rate, the units of the target currency bought by one unit of m's currency, and rounds the
+// result to the target minor units with mode.
+func (m Money) Convert(rate Decimal, target string, mode RoundingMode) Money {
+	return Money{Amount: m.Amount.Mul(rate), Currency: target}.RoundWith(mode)
+}
+
+func (m Money) String() string {
+	return m.Amount.StringFixed(MinorUnits(m.Currency)) + " " + m.Currency
+}
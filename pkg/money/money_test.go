@@ -0,0 +1,127 @@
+package money_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"gopkg.in/yaml.v3"
+	"testing"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecimalJSONKeepsDigits(t *testing.T) {
+	// Given
+	var d money.Decimal
+	// When
+	err := json.Unmarshal([]byte(`0.1000000000000000055511151231257827`), &d)
+	// Then
+	assert.Nil(t, err)
+	bytes, err := json.Marshal(d)
+	assert.Nil(t, err)
+	assert.Equal(t, `0.1000000000000000055511151231257827`, string(bytes))
+}
+
+func TestDecimalJSONAcceptsQuotedNumbers(t *testing.T) {
+	// Given
+	var d money.Decimal
+	// When
+	err := json.Unmarshal([]byte(`"19.99"`), &d)
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "19.99", d.String())
+}
+
+func TestDecimalJSONInvalid(t *testing.T) {
+	// Given
+	var d money.Decimal
+	// When
+	err := json.Unmarshal([]byte(`"nineteen"`), &d)
+	// Then
+	assert.NotNil(t, err)
+}
+
+func TestDecimalPlaces(t *testing.T) {
+	assert.Equal(t, int32(0), money.RequireFromString("1500").Places())
+	assert.Equal(t, int32(1), money.RequireFromString("1.50").Places())
+	assert.Equal(t, int32(6), money.RequireFromString("0.000001").Places())
+	assert.Equal(t, int32(7), money.RequireFromString("-2.0000001").Places())
+}
+
+func TestDecimalTextKeepsDigits(t *testing.T) {
+	// Given
+	var fromYAML struct {
+		Rate money.Decimal `yaml:"rate"`
+	}
+	var fromXML struct {
+		Rate money.Decimal `xml:"rate,attr"`
+	}
+	// When
+	yamlErr := yaml.Unmarshal([]byte("rate: 828.503912"), &fromYAML)
+	xmlErr := xml.Unmarshal([]byte(`<Cube rate="1.1448"/>`), &fromXML)
+	// Then
+	assert.Nil(t, yamlErr)
+	assert.Nil(t, xmlErr)
+	assert.Equal(t, "828.503912", fromYAML.Rate.String())
+	assert.Equal(t, "1.1448", fromXML.Rate.String())
+	assert.NotNil(t, yaml.Unmarshal([]byte("rate: lots"), &fromYAML))
+}
+
+func TestDecimalMaths(t *testing.T) {
+	// Given
+	price := money.RequireFromString("19.99")
+	// When
+	total := price.Mul(money.NewFromInt(3)).Add(money.RequireFromString("0.01"))
+	// Then
+	assert.Equal(t, "59.98", total.String())
+	assert.True(t, total.Equal(money.RequireFromString("59.980")))
+	assert.Equal(t, 1, total.Cmp(price))
+}
+
+func TestRoundingModes(t *testing.T) {
+	cases := map[string][]string{
+		// mode: rounding of 2.345, 2.355 and -2.345 to two places
+		"half_up":   {"2.35", "2.36", "-2.35"},
+		"half_even": {"2.34", "2.36", "-2.34"},
+		"up":        {"2.35", "2.36", "-2.35"},
+		"down":      {"2.34", "2.35", "-2.34"},
+		"ceiling":   {"2.35", "2.36", "-2.34"},
+		"floor":     {"2.34", "2.35", "-2.35"},
+	}
+	for name, expected := range cases {
+		mode, err := money.ParseRoundingMode(name)
+		assert.Nil(t, err)
+		for i, value := range []string{"2.345", "2.355", "-2.345"} {
+			assert.Equal(t, expected[i], money.RequireFromString(value).Round(2, mode).String(), name+" "+value)
+		}
+	}
+}
+
+func TestParseRoundingModeUnknown(t *testing.T) {
+	_, err := money.ParseRoundingMode("bankers")
+	assert.NotNil(t, err)
+}
+
+func TestMinorUnits(t *testing.T) {
+	assert.Equal(t, int32(2), money.MinorUnits("USD"))
+	assert.Equal(t, int32(0), money.MinorUnits("CLP"))
+	assert.Equal(t, int32(0), money.MinorUnits("jpy"))
+	assert.Equal(t, int32(3), money.MinorUnits("KWD"))
+}
+
+func TestMoneyConvertRoundsToTargetMinorUnits(t *testing.T) {
+	// Given
+	m := money.New(money.RequireFromString("6140.592"), "ARS")
+	rate := money.RequireFromString("828.503912").Div(money.RequireFromString("105.356594"))
+	// When
+	converted := m.Convert(rate, "CLP", money.HalfUp)
+	// Then
+	assert.Equal(t, "48288", converted.Amount.String())
+	assert.Equal(t, "48288 CLP", converted.String())
+}
+
+func TestMoneyRoundWith(t *testing.T) {
+	m := money.New(money.RequireFromString("10.125"), "USD")
+	assert.Equal(t, "10.13 USD", m.RoundWith(money.HalfUp).String())
+	assert.Equal(t, "10.12 USD", m.RoundWith(money.HalfEven).String())
+}
@@ -0,0 +1,17 @@
+package refdata
+
+import (
+	"net/http"
+
+	"github.com/rgraterol/beers-api/pkg/responses"
+)
+
+// ListCurrencies answers the ISO-4217 currencies accepted by the API.
+func ListCurrencies(w http.ResponseWriter, _ *http.Request) {
+	responses.OK(w, currencies)
+}
+
+// ListCountries answers the ISO-3166 countries accepted by the API.
+func ListCountries(w http.ResponseWriter, _ *http.Request) {
+	responses.OK(w, countries)
+}
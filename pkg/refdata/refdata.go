@@ -0,0 +1,132 @@
+package refdata
+
+import (
+	"embed"
+	"encoding/csv"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed data/*.csv
+var data embed.FS
+
+// Currency is an ISO-4217 circulating currency, MinorUnits is the amount of decimal places of its amounts.
+type Currency struct {
+	Code       string `json:"code"`
+	Numeric    string `json:"numeric"`
+	Name       string `json:"name"`
+	MinorUnits int32  `json:"minor_units"`
+}
+
+// Country is an ISO-3166-1 country, Aliases are common names it is also known by.
+type Country struct {
+	Alpha2  string   `json:"alpha2"`
+	Alpha3  string   `json:"alpha3"`
+	Numeric string   `json:"numeric"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+var (
+	currencies      []Currency
+	currenciesByKey map[string]int
+	countries       []Country
+	countriesByKey  map[string]int
+)
+
+func init() {
+	if err := loadCurrencies(); err != nil {
+		panic(err)
+	}
+	if err := loadCountries(); err != nil {
+		panic(err)
+	}
+}
+
+// Currencies lists every currency sorted by code.
+func Currencies() []Currency {
+	return append([]Currency(nil), currencies...)
+}
+
+// LookupCurrency finds a currency by its code, case insensitive.
+func LookupCurrency(code string) (Currency, bool) {
+	i, ok := currenciesByKey[normaliseKey(code)]
+	if !ok {
+		return Currency{}, false
+	}
+	return currencies[i], true
+}
+
+// Countries lists every country in the ISO-3166 order, by English name.
+func Countries() []Country {
+	return append([]Country(nil), countries...)
+}
+
+// LookupCountry finds a country by its alpha-2 or alpha-3 code, its name or one of its aliases, case
+// insensitive.
+func LookupCountry(value string) (Country, bool) {
+	i, ok := countriesByKey[normaliseKey(value)]
+	if !ok {
+		return Country{}, false
+	}
+	return countries[i], true
+}
+
+func normaliseKey(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+func loadCurrencies() error {
+	records, err := readCSV("data/currencies.csv")
+	if err != nil {
+		return err
+	}
+	currenciesByKey = make(map[string]int, len(records))
+	for _, record := range records {
+		units, err := strconv.Atoi(record[2])
+		if err != nil {
+			return errors.Wrap(err, "invalid minor units of "+record[0])
+		}
+		currencies = append(currencies, Currency{Code: record[0], Numeric: record[1], Name: record[3], MinorUnits: int32(units)})
+		currenciesByKey[normaliseKey(record[0])] = len(currencies) - 1
+	}
+	return nil
+}
+
+func loadCountries() error {
+	records, err := readCSV("data/countries.csv")
+	if err != nil {
+		return err
+	}
+	countriesByKey = make(map[string]int, 3*len(records))
+	for _, record := range records {
+		c := Country{Alpha2: record[0], Alpha3: record[1], Numeric: record[2], Name: record[3]}
+		if record[4] != "" {
+			c.Aliases = strings.Split(record[4], "|")
+		}
+		countries = append(countries, c)
+		for _, key := range append([]string{c.Alpha2, c.Alpha3, c.Name}, c.Aliases...) {
+			countriesByKey[normaliseKey(key)] = len(countries) - 1
+		}
+	}
+	return nil
+}
+
+// readCSV reads an embedded file skipping its header.
+func readCSV(name string) ([][]string, error) {
+	file, err := data.Open(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot open "+name)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read "+name)
+	}
+	if len(records) == 0 {
+		return nil, errors.New(name + " is empty")
+	}
+	return records[1:], nil
+}
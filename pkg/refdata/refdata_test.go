@@ -0,0 +1,83 @@
+package refdata_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rgraterol/beers-api/pkg/refdata"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddedData(t *testing.T) {
+	// Given
+	// When
+	currencies, countries := refdata.Currencies(), refdata.Countries()
+	// Then
+	assert.Len(t, currencies, 155)
+	assert.Len(t, countries, 249)
+}
+
+func TestLookupCurrency(t *testing.T) {
+	// Given
+	// When
+	clp, okCLP := refdata.LookupCurrency(" clp ")
+	kwd, okKWD := refdata.LookupCurrency("KWD")
+	_, okUnknown := refdata.LookupCurrency("XYZ")
+	// Then
+	assert.True(t, okCLP)
+	assert.Equal(t, "CLP", clp.Code)
+	assert.Equal(t, int32(0), clp.MinorUnits)
+	assert.True(t, okKWD)
+	assert.Equal(t, int32(3), kwd.MinorUnits)
+	assert.False(t, okUnknown)
+}
+
+func TestLookupCountry(t *testing.T) {
+	// Given
+	values := []string{"CL", "chl", "chile", " Chile "}
+	for _, value := range values {
+		// When
+		country, ok := refdata.LookupCountry(value)
+		// Then
+		assert.True(t, ok, value)
+		assert.Equal(t, "Chile", country.Name)
+		assert.Equal(t, "CHL", country.Alpha3)
+	}
+}
+
+func TestLookupCountryAlias(t *testing.T) {
+	// Given
+	// When
+	country, ok := refdata.LookupCountry("south korea")
+	_, okUnknown := refdata.LookupCountry("Narnia")
+	// Then
+	assert.True(t, ok)
+	assert.Equal(t, "KR", country.Alpha2)
+	assert.Equal(t, "Korea, Republic of", country.Name)
+	assert.False(t, okUnknown)
+}
+
+func TestCurrenciesAreCopies(t *testing.T) {
+	// Given
+	currencies := refdata.Currencies()
+	// When
+	currencies[0].Code = "ZZZ"
+	// Then
+	assert.NotEqual(t, "ZZZ", refdata.Currencies()[0].Code)
+}
+
+func TestListCurrencies200(t *testing.T) {
+	//GIVEN
+	w := httptest.NewRecorder()
+	//WHEN
+	refdata.ListCurrencies(w, httptest.NewRequest(http.MethodGet, "/currencies", nil))
+	var resp []map[string]interface{}
+	err := json.NewDecoder(w.Result().Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Len(t, resp, 155)
+	assert.Contains(t, resp[0], "minor_units")
+}
@@ -0,0 +1,275 @@
+package responses
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	JSONMediaType    = "application/json"
+	XMLMediaType     = "application/xml"
+	CSVMediaType     = "text/csv"
+	MsgPackMediaType = "application/msgpack"
+)
+
+// UnsupportedError is answered by the encoders that cannot represent a payload, such as CSV with a single object.
+var UnsupportedError = errors.New("the payload has no representation on the media type")
+
+// Encoder writes v on its media type.
+type Encoder func(w io.Writer, v interface{}) error
+
+type registeredEncoder struct {
+	mediaType string
+	encode    Encoder
+}
+
+// encoders are negotiated in order, the first one answers the requests without preferences. They are guarded by
+// encodersMu since encoders can be registered while requests are served.
+var (
+	encodersMu sync.RWMutex
+	encoders   = []registeredEncoder{
+		{mediaType: JSONMediaType, encode: encodeJSON},
+		{mediaType: XMLMediaType, encode: encodeXML},
+		{mediaType: CSVMediaType, encode: encodeCSV},
+		{mediaType: MsgPackMediaType, encode: encodeMsgPack},
+		{mediaType: "application/x-msgpack", encode: encodeMsgPack},
+	}
+)
+
+// RegisterEncoder makes mediaType negotiable, replacing its current encoder if any.
+func RegisterEncoder(mediaType string, encode Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	for i := range encoders {
+		if encoders[i].mediaType == mediaType {
+			encoders[i].encode = encode
+			return
+		}
+	}
+	encoders = append(encoders, registeredEncoder{mediaType: mediaType, encode: encode})
+}
+
+// MediaTypes answers the negotiable media types, in order of preference.
+func MediaTypes() []string {
+	registered := registeredEncoders()
+	mediaTypes := make([]string, len(registered))
+	for i, e := range registered {
+		mediaTypes[i] = e.mediaType
+	}
+	return mediaTypes
+}
+
+// registeredEncoders answers a copy of the encoders, safe to use while others are registered.
+func registeredEncoders() []registeredEncoder {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	return append([]registeredEncoder(nil), encoders...)
+}
+
+// negotiate encodes v with the encoder the accept header prefers, falling back to the next ones when an encoder
+// cannot represent v. It answers false when no accepted encoder can.
+func negotiate(accept string, v interface{}) (string, []byte, bool) {
+	for _, e := range acceptedEncoders(accept) {
+		var buf bytes.Buffer
+		if err := e.encode(&buf, v); err == nil {
+			return e.mediaType, buf.Bytes(), true
+		}
+	}
+	return "", nil, false
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+// acceptedEncoders sorts the encoders by the quality the accept header gives them, leaving out the unacceptable.
+func acceptedEncoders(accept string) []registeredEncoder {
+	registered := registeredEncoders()
+	if strings.TrimSpace(accept) == "" {
+		return registered
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality})
+	}
+	qualities := make(map[string]float64)
+	var accepted []registeredEncoder
+	for _, e := range registered {
+		if q := rangeQuality(ranges, e.mediaType); q > 0 {
+			qualities[e.mediaType] = q
+			accepted = append(accepted, e)
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return qualities[accepted[i].mediaType] > qualities[accepted[j].mediaType]
+	})
+	return accepted
+}
+
+// rangeQuality is the quality of the most specific range matching mediaType, zero when none does.
+func rangeQuality(ranges []mediaRange, mediaType string) float64 {
+	quality, specificity := 0.0, -1
+	mainType := strings.SplitN(mediaType, "/", 2)[0]
+	for _, r := range ranges {
+		s := -1
+		switch r.mediaType {
+		case mediaType:
+			s = 2
+		case mainType + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		}
+		if s > specificity {
+			quality, specificity = r.quality, s
+		}
+	}
+	return quality
+}
+
+func encodeJSON(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// encodeXML lays out the JSON of v under a response element, array items are item elements.
+func encodeXML(w io.Writer, v interface{}) error {
+	tree, err := toTree(v)
+	if err != nil {
+		return err
+	}
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if err = encodeXMLElement(enc, "response", tree); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+func encodeXMLElement(enc *xml.Encoder, name string, value interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	switch t := value.(type) {
+	case object:
+		for _, m := range t {
+			if err := encodeXMLElement(enc, m.key, m.value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range t {
+			if err := encodeXMLElement(enc, "item", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := enc.EncodeToken(xml.CharData(scalarText(t))); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// encodeCSV writes lists, either arrays of objects or objects holding them on results, a row per item.
+func encodeCSV(w io.Writer, v interface{}) error {
+	tree, err := toTree(v)
+	if err != nil {
+		return err
+	}
+	if o, ok := tree.(object); ok {
+		tree = nil
+		for _, m := range o {
+			if m.key == "results" {
+				tree = m.value
+			}
+		}
+	}
+	items, ok := tree.([]interface{})
+	if !ok {
+		return UnsupportedError
+	}
+	// Omitted empty fields can make later items carry columns the first one doesn't
+	var header []string
+	columns := make(map[string]bool)
+	for _, item := range items {
+		o, ok := item.(object)
+		if !ok {
+			return UnsupportedError
+		}
+		for _, m := range o {
+			if !columns[m.key] {
+				columns[m.key] = true
+				header = append(header, m.key)
+			}
+		}
+	}
+	cw := csv.NewWriter(w)
+	if err = cw.Write(header); err != nil {
+		return err
+	}
+	for _, item := range items {
+		values := make(map[string]string)
+		for _, m := range item.(object) {
+			if values[m.key], err = csvCell(m.value); err != nil {
+				return err
+			}
+		}
+		record := make([]string, len(header))
+		for i, column := range header {
+			record[i] = values[column]
+		}
+		if err = cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvCell writes nested values as their JSON.
+func csvCell(value interface{}) (string, error) {
+	switch value.(type) {
+	case object, []interface{}:
+		data, err := json.Marshal(value)
+		return string(data), err
+	case nil:
+		return "", nil
+	}
+	return scalarText(value), nil
+}
+
+func scalarText(value interface{}) string {
+	switch t := value.(type) {
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case bool:
+		return strconv.FormatBool(t)
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package responses_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rgraterol/beers-api/pkg/responses"
+	"github.com/stretchr/testify/assert"
+)
+
+type beerMock struct {
+	ID      int64   `json:"id"`
+	Name    string  `json:"name"`
+	Country string  `json:"country,omitempty"`
+	Price   float64 `json:"price"`
+}
+
+type pageMock struct {
+	Results []beerMock `json:"results"`
+	Total   int        `json:"total"`
+}
+
+func pageOf() pageMock {
+	return pageMock{
+		Results: []beerMock{{ID: 1, Name: "Golden", Price: 2.5}, {ID: 2, Name: "Bock, dark", Country: "Chile", Price: 3}},
+		Total:   2,
+	}
+}
+
+func negotiate(accept string, response interface{}) *httptest.ResponseRecorder {
+	handler := responses.Negotiation(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		responses.OK(w, response)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/beers", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestNegotiateJSONByDefault(t *testing.T) {
+	// Given
+	// When
+	w := negotiate("", pageOf())
+	// Then
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, responses.JSONMediaType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+	assert.JSONEq(t, `{"results":[{"id":1,"name":"Golden","price":2.5},
+		{"id":2,"name":"Bock, dark","country":"Chile","price":3}],"total":2}`, w.Body.String())
+}
+
+func TestNegotiateByQuality(t *testing.T) {
+	// Given
+	// When
+	w := negotiate("application/json;q=0.5, application/*;q=0.8, text/csv;q=0", pageOf())
+	// Then
+	assert.Equal(t, responses.XMLMediaType, w.Header().Get("Content-Type"))
+}
+
+func TestNegotiateXML(t *testing.T) {
+	// Given
+	// When
+	w := negotiate("application/xml", pageOf())
+	// Then
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<response><results>`+
+		`<item><id>1</id><name>Golden</name><price>2.5</price></item>`+
+		`<item><id>2</id><name>Bock, dark</name><country>Chile</country><price>3</price></item>`+
+		`</results><total>2</total></response>`, w.Body.String())
+}
+
+func TestNegotiateCSV(t *testing.T) {
+	// Given
+	// When
+	w := negotiate("text/csv", pageOf())
+	// Then
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, responses.CSVMediaType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "id,name,price,country\n1,Golden,2.5,\n2,\"Bock, dark\",3,Chile\n", w.Body.String())
+}
+
+func TestNegotiateCSVFallsBackToNextAccepted(t *testing.T) {
+	// Given
+	// When
+	w := negotiate("text/csv, application/json;q=0.1", beerMock{ID: 1, Name: "Golden"})
+	// Then
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, responses.JSONMediaType, w.Header().Get("Content-Type"))
+}
+
+func TestNegotiateNotAcceptable406(t *testing.T) {
+	// Given
+	// When
+	w := negotiate("text/csv", beerMock{ID: 1, Name: "Golden"})
+	// Then
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Equal(t, responses.JSONMediaType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "the response cannot be represented on any accepted media type")
+}
+
+func TestNegotiateMsgPack(t *testing.T) {
+	// Given
+	response := map[string]interface{}{"name": "Golden", "price": 2.5, "ids": []int{1, -1, 300}, "deleted": nil}
+	// When
+	w := negotiate("application/msgpack", response)
+	// Then
+	assert.Equal(t, responses.MsgPackMediaType, w.Header().Get("Content-Type"))
+	assert.Equal(t, []byte{
+		0x84,
+		0xa7, 'd', 'e', 'l', 'e', 't', 'e', 'd', 0xc0,
+		0xa3, 'i', 'd', 's', 0x93, 0x01, 0xff, 0xd1, 0x01, 0x2c,
+		0xa4, 'n', 'a', 'm', 'e', 0xa6, 'G', 'o', 'l', 'd', 'e', 'n',
+		0xa5, 'p', 'r', 'i', 'c', 'e', 0xcb, 0x40, 0x04, 0, 0, 0, 0, 0, 0,
+	}, w.Body.Bytes())
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	// Given
+	responses.RegisterEncoder("text/plain", func(w io.Writer, v interface{}) error {
+		_, err := io.WriteString(w, "plain")
+		return err
+	})
+	// When
+	w := negotiate("text/plain", pageOf())
+	// Then
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, "plain", w.Body.String())
+	assert.True(t, strings.HasPrefix(strings.Join(responses.MediaTypes(), ","), responses.JSONMediaType))
+}
+
+func TestRegisterEncoderWhileNegotiating(t *testing.T) {
+	// Given
+	var wg sync.WaitGroup
+	wg.Add(2)
+	// When
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			responses.RegisterEncoder("text/x-concurrent", func(w io.Writer, v interface{}) error {
+				_, err := io.WriteString(w, "concurrent")
+				return err
+			})
+		}
+	}()
+	codes := make([]int, 100)
+	go func() {
+		defer wg.Done()
+		for i := range codes {
+			codes[i] = negotiate("", pageOf()).Code
+		}
+	}()
+	wg.Wait()
+	// Then
+	for _, code := range codes {
+		assert.Equal(t, http.StatusOK, code)
+	}
+	assert.Contains(t, responses.MediaTypes(), "text/x-concurrent")
+}
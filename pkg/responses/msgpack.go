@@ -0,0 +1,119 @@
+package responses
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+)
+
+// encodeMsgPack writes the JSON of v as MessagePack, numbers are integers when they have no decimals and float64
+// otherwise.
+func encodeMsgPack(w io.Writer, v interface{}) error {
+	tree, err := toTree(v)
+	if err != nil {
+		return err
+	}
+	var buf []byte
+	buf, err = appendMsgPack(buf, tree)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func appendMsgPack(buf []byte, value interface{}) ([]byte, error) {
+	var err error
+	switch t := value.(type) {
+	case nil:
+		return append(buf, 0xc0), nil
+	case bool:
+		if t {
+			return append(buf, 0xc3), nil
+		}
+		return append(buf, 0xc2), nil
+	case string:
+		return appendMsgPackString(buf, t), nil
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return appendMsgPackInt(buf, i), nil
+		}
+		f, err := t.Float64()
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, 0xcb)
+		return appendUint64(buf, math.Float64bits(f)), nil
+	case []interface{}:
+		buf = appendMsgPackHeader(buf, len(t), 0x90, 0xdc, 0xdd)
+		for _, item := range t {
+			if buf, err = appendMsgPack(buf, item); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case object:
+		buf = appendMsgPackHeader(buf, len(t), 0x80, 0xde, 0xdf)
+		for _, m := range t {
+			buf = appendMsgPackString(buf, m.key)
+			if buf, err = appendMsgPack(buf, m.value); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+	return nil, UnsupportedError
+}
+
+func appendMsgPackString(buf []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = appendUint16(append(buf, 0xda), uint16(n))
+	default:
+		buf = appendUint32(append(buf, 0xdb), uint32(n))
+	}
+	return append(buf, s...)
+}
+
+// appendMsgPackHeader writes the size of an array or map, fix holds up to 15 items.
+func appendMsgPackHeader(buf []byte, n int, fix byte, size16 byte, size32 byte) []byte {
+	switch {
+	case n < 16:
+		return append(buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(buf, size16), uint16(n))
+	}
+	return appendUint32(append(buf, size32), uint32(n))
+}
+
+func appendMsgPackInt(buf []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		return append(buf, byte(i))
+	case i < 0 && i >= -32:
+		return append(buf, byte(i))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		return append(buf, 0xd0, byte(i))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		return appendUint16(append(buf, 0xd1), uint16(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		return appendUint32(append(buf, 0xd2), uint32(i))
+	}
+	return appendUint64(append(buf, 0xd3), uint64(i))
+}
+
+func appendUint16(buf []byte, v uint16) []byte {
+	return append(buf, byte(v>>8), byte(v))
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	return append(buf, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	return appendUint32(appendUint32(buf, uint32(v>>32)), uint32(v))
+}
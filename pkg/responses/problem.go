@@ -0,0 +1,112 @@
+package responses
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rgraterol/beers-api/pkg/tracing"
+)
+
+// ProblemMediaType is the RFC 7807 content type of the problem details.
+const ProblemMediaType = "application/problem+json"
+
+// Problem is an RFC 7807 error answer, RequestID and Cause are extension members.
+type Problem struct {
+	Type      string      `json:"type"`
+	Title     string      `json:"title"`
+	Status    int         `json:"status"`
+	Detail    string      `json:"detail,omitempty"`
+	Instance  string      `json:"instance,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+	Cause     interface{} `json:"cause,omitempty"`
+}
+
+// Negotiation middleware lets the responses know the request they answer, to negotiate their encoder and to pick
+// the format of the errors, filling the instance and request id of the problems. It must be the last middleware
+// before the routes.
+func Negotiation(next http.Handler) http.Handler {
+	return ProblemNegotiation(false)(next)
+}
+
+// ProblemNegotiation is the Negotiation middleware answering every error as problem+json when problemDetails is
+// set, otherwise only the requests accepting ProblemMediaType get it.
+func ProblemNegotiation(problemDetails bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&requestWriter{ResponseWriter: w, request: r, problemDetails: problemDetails}, r)
+		})
+	}
+}
+
+// requestWriter keeps the request along its ResponseWriter, flushes are passed through so streams keep working.
+type requestWriter struct {
+	http.ResponseWriter
+	request        *http.Request
+	problemDetails bool
+}
+
+func (w *requestWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *requestWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func newProblem(w http.ResponseWriter, status int, detail string, cause interface{}) *Problem {
+	p := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+	if rw, ok := w.(*requestWriter); ok {
+		p.Instance = rw.request.URL.Path
+		p.RequestID = middleware.GetReqID(rw.request.Context())
+	}
+	// Empty causes are left out, as the members a problem doesn't need
+	if c, ok := cause.([]string); !ok || len(c) > 0 {
+		p.Cause = cause
+	}
+	return &p
+}
+
+// acceptOf answers the Accept header of the request answered on w, empty when it is unknown.
+func acceptOf(w http.ResponseWriter) string {
+	if rw, ok := w.(*requestWriter); ok {
+		return rw.request.Header.Get("Accept")
+	}
+	return ""
+}
+
+// loggerOf answers the logger of the request answered on w.
+func loggerOf(w http.ResponseWriter) *zap.SugaredLogger {
+	if rw, ok := w.(*requestWriter); ok {
+		return tracing.Logger(rw.request.Context())
+	}
+	return tracing.Logger(context.Background())
+}
+
+// wantsProblem tells if the error answered on w must be a problem.
+func wantsProblem(w http.ResponseWriter) bool {
+	if rw, ok := w.(*requestWriter); ok && rw.problemDetails {
+		return true
+	}
+	return acceptsProblem(acceptOf(w))
+}
+
+func acceptsProblem(accept string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err == nil && mediaType == ProblemMediaType {
+			return true
+		}
+	}
+	return false
+}
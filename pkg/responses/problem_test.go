@@ -0,0 +1,93 @@
+package responses_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rgraterol/beers-api/pkg/responses"
+	"github.com/rgraterol/beers-api/pkg/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAbortJSONByDefault(t *testing.T) {
+	// Given
+	handler := responses.Negotiation(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		responses.NotFound(w, "beer not found")
+	}))
+	w := httptest.NewRecorder()
+	// When
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/beers/1", nil))
+	var resp map[string]interface{}
+	err := json.NewDecoder(w.Body).Decode(&resp)
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "beer not found", resp["message"])
+	assert.Equal(t, []interface{}{}, resp["cause"])
+}
+
+func TestAbortProblemWhenAccepted(t *testing.T) {
+	// Given
+	handler := middleware.RequestID(responses.Negotiation(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		responses.NotFound(w, "beer not found")
+	})))
+	req := httptest.NewRequest(http.MethodGet, "/beers/1?currency=USD", nil)
+	req.Header.Set("Accept", "application/json;q=0.9, application/problem+json")
+	w := httptest.NewRecorder()
+	// When
+	handler.ServeHTTP(w, req)
+	var problem responses.Problem
+	err := json.NewDecoder(w.Body).Decode(&problem)
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, responses.ProblemMediaType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, "Not Found", problem.Title)
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, "beer not found", problem.Detail)
+	assert.Equal(t, "/beers/1", problem.Instance)
+	assert.NotEmpty(t, problem.RequestID)
+	assert.Nil(t, problem.Cause)
+}
+
+func TestErrorProblemFromConfig(t *testing.T) {
+	// Given
+	handler := responses.ProblemNegotiation(true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		responses.Error(w, validation.NewFieldError("name", validation.Required, "name cannot be empty"))
+	}))
+	w := httptest.NewRecorder()
+	// When
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/beers", nil))
+	var problem map[string]interface{}
+	err := json.NewDecoder(w.Body).Decode(&problem)
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, responses.ProblemMediaType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "name cannot be empty", problem["detail"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "name", "rule": "required", "message": "name cannot be empty"},
+	}, problem["cause"])
+}
+
+func TestProblemsKeepsFlushing(t *testing.T) {
+	// Given
+	var flushed bool
+	handler := responses.Negotiation(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		flushed = ok
+		if ok {
+			f.Flush()
+		}
+	}))
+	w := httptest.NewRecorder()
+	// When
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/beers/export", nil))
+	// Then
+	assert.True(t, flushed)
+	assert.True(t, w.Flushed)
+}
@@ -1,12 +1,19 @@
 package responses
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/pkg/validation"
 )
 
+// StatusClientClosedRequest is answered, as nginx does, when the client went away before the response was ready.
+const StatusClientClosedRequest = 499
+
 func OK(w http.ResponseWriter, response interface{}) {
 	answer(w, http.StatusOK, response)
 }
@@ -15,6 +22,15 @@ func Created(w http.ResponseWriter, response interface{}) {
 	answer(w, http.StatusCreated, response)
 }
 
+// Unavailable answers response with a 503, for the reports of unhealthy dependencies.
+func Unavailable(w http.ResponseWriter, response interface{}) {
+	answer(w, http.StatusServiceUnavailable, response)
+}
+
+func NoContent(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func Duplicated(w http.ResponseWriter, response string) {
 	Abort(w, http.StatusConflict, response)
 }
@@ -23,32 +39,100 @@ func BadRequest(w http.ResponseWriter, response string) {
 	Abort(w, http.StatusBadRequest, response)
 }
 
+func Forbidden(w http.ResponseWriter, response string) {
+	Abort(w, http.StatusForbidden, response)
+}
+
+func UnsupportedMediaType(w http.ResponseWriter, response string) {
+	Abort(w, http.StatusUnsupportedMediaType, response)
+}
+
 func NotFound(w http.ResponseWriter, response string) {
 	Abort(w, http.StatusNotFound, response)
 }
 
+// Error answers err with its StatusCode, or 500 when it has none. Validation errors fill the cause with every
+// broken field, and the work cut short by the request context answers a 504 on timeouts or a 499 on disconnects.
+// Server errors answer the text of their status only.
 func Error(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		status = StatusClientClosedRequest
+	}
 	if e, ok := errors.Cause(err).(interface {
 		StatusCode() int
 	}); ok {
-		answer(w, e.StatusCode(), e)
+		status = e.StatusCode()
+	}
+	if e, ok := errors.Cause(err).(interface {
+		Causes() []validation.FieldError
+	}); ok {
+		abort(w, status, err.Error(), e.Causes())
+		return
+	}
+	// The server errors come from the DB or the upstream APIs, their details are logged and not answered
+	if status >= http.StatusInternalServerError {
+		loggerOf(w).Error(err)
+		Abort(w, status, http.StatusText(status))
 		return
 	}
-	Abort(w, http.StatusInternalServerError, err.Error())
+	Abort(w, status, err.Error())
 }
 
 func Abort(w http.ResponseWriter, status int, message string) {
-	answer(w, status, map[string]interface{}{
-		"status":  status,
-		"error":   http.StatusText(status),
-		"message": message,
-		"cause":   make([]string, 0),
-	})
+	abort(w, status, message, make([]string, 0))
+}
+
+// errorResponse is the body of the errors that are not problems.
+type errorResponse struct {
+	Status  int         `json:"status"`
+	Error   string      `json:"error"`
+	Message string      `json:"message"`
+	Cause   interface{} `json:"cause"`
+}
+
+// abort answers an error, as a Problem when the request or the config asks for it.
+func abort(w http.ResponseWriter, status int, message string, cause interface{}) {
+	if wantsProblem(w) {
+		body, _ := json.Marshal(newProblem(w, status, message, cause))
+		write(w, status, ProblemMediaType, append(body, '\n'))
+		return
+	}
+	reply(w, status, errorResponse{
+		Status:  status,
+		Error:   http.StatusText(status),
+		Message: message,
+		Cause:   cause,
+	}, true)
 }
 
 func answer(w http.ResponseWriter, status int, response interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(response)
+	reply(w, status, response, false)
 }
 
+// reply encodes response with the encoder negotiated with the request. When no accepted encoder can represent it
+// the request is answered with a 406, unless response is already an error, which falls back to JSON.
+func reply(w http.ResponseWriter, status int, response interface{}, isError bool) {
+	w.Header().Set("Vary", "Accept")
+	mediaType, body, ok := negotiate(acceptOf(w), response)
+	if !ok && !isError {
+		Abort(w, http.StatusNotAcceptable, "the response cannot be represented on any accepted media type, "+
+			"the available ones are "+strings.Join(MediaTypes(), ", "))
+		return
+	}
+	if !ok {
+		var buf bytes.Buffer
+		encodeJSON(&buf, response)
+		mediaType, body = JSONMediaType, buf.Bytes()
+	}
+	write(w, status, mediaType, body)
+}
+
+func write(w http.ResponseWriter, status int, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write(body)
+}
@@ -0,0 +1,40 @@
+package responses_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/pkg/responses"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorContextDone(t *testing.T) {
+	cases := map[error]int{
+		errors.Wrap(context.DeadlineExceeded, "cannot get currency layer API"): http.StatusGatewayTimeout,
+		context.Canceled:                 responses.StatusClientClosedRequest,
+		errors.New("connection refused"): http.StatusInternalServerError,
+	}
+	for err, status := range cases {
+		// Given
+		w := httptest.NewRecorder()
+		// When
+		responses.Error(w, err)
+		// Then
+		assert.Equal(t, status, w.Code, err.Error())
+	}
+}
+
+func TestErrorHidesServerErrorDetails(t *testing.T) {
+	// Given
+	w := httptest.NewRecorder()
+	err := errors.Wrap(errors.New("Get \"http://api.currencylayer.com/live?access_key=SECRETKEY\": refused"), "cannot get rates")
+	// When
+	responses.Error(w, err)
+	// Then
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "SECRETKEY")
+	assert.Contains(t, w.Body.String(), `"message":"Internal Server Error"`)
+}
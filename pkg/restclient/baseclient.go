@@ -1,19 +1,60 @@
 package restclient
 
 import (
+	"context"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/metrics"
+	"github.com/rgraterol/beers-api/pkg/tracing"
 )
 
 type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
-var Client HTTPClient
+// Client calls the upstream APIs through HTTP, recording the metrics and the spans of every request.
+type Client struct {
+	HTTP HTTPClient
+
+	requests *metrics.CounterVec
+	duration *metrics.HistogramVec
+}
+
+// New records the metrics of the requests on registry.
+func New(httpClient HTTPClient, registry *metrics.Registry) *Client {
+	return &Client{
+		HTTP: httpClient,
+		requests: metrics.NewCounterVec(registry, "upstream_requests_total",
+			"Requests to the upstream APIs by host and status, error when no response came.", "host", "status"),
+		duration: metrics.NewHistogramVec(registry, "upstream_request_duration_seconds",
+			"Latency of the requests to the upstream APIs by host.", nil, "host"),
+	}
+}
 
-func Get(url string) (*http.Response, error) {
-	request, err := http.NewRequest(http.MethodGet, url, nil)
+// Get requests url bound to ctx, so the request is cancelled with it.
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	ctx, span := tracing.Start(ctx, "HTTP GET", tracing.KindClient)
+	defer span.End()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
+		span.RecordError(err)
 		return nil, err
 	}
-	return Client.Do(request)
+	span.SetAttribute("http.method", http.MethodGet)
+	span.SetAttribute("net.peer.name", request.URL.Host)
+	tracing.Inject(ctx, request.Header)
+
+	start := time.Now()
+	resp, err := c.HTTP.Do(request)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+		span.SetAttribute("http.status_code", resp.StatusCode)
+	}
+	span.RecordError(err)
+	c.requests.Inc(request.URL.Host, status)
+	c.duration.Observe(time.Since(start).Seconds(), request.URL.Host)
+	return resp, err
 }
@@ -1,15 +1,20 @@
 package restclient
 
-import "net/http"
+import (
+	"net/http"
+
+	"github.com/rgraterol/beers-api/pkg/metrics"
+)
 
 type MockClient struct {
 	DoFuncMock func(req *http.Request) (*http.Response, error)
 }
 
-var (
-	GetDoFuncMock func(req *http.Request) (*http.Response, error)
-)
-
 func (m *MockClient) Do(req *http.Request) (*http.Response, error) {
-	return GetDoFuncMock(req)
+	return m.DoFuncMock(req)
+}
+
+// NewMock answers a Client whose requests are answered by doFuncMock, its metrics are not served.
+func NewMock(doFuncMock func(req *http.Request) (*http.Response, error)) *Client {
+	return New(&MockClient{DoFuncMock: doFuncMock}, &metrics.Registry{})
 }
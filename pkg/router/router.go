@@ -4,20 +4,40 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/rgraterol/beers-api/pkg/app"
+	"github.com/rgraterol/beers-api/pkg/health"
+	"github.com/rgraterol/beers-api/pkg/refdata"
 	"github.com/rgraterol/beers-api/pkg/responses"
 	"github.com/rgraterol/beers-api/pkg/usecases/beers"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
 )
 
-func Routes(r *chi.Mux) {
+// ExportPath streams the beers catalog, it takes longer than the other endpoints.
+const ExportPath = "/beers/export"
+
+// Routes serves the endpoints of a on r.
+func Routes(r *chi.Mux, a *app.App) {
 	r.Get("/ping", basePingHandler)
+	r.Get("/health/live", health.Handler(a.Liveness))
+	r.Get("/health/ready", health.Handler(a.Readiness))
 
 	r.Route("/beers", func(r chi.Router) {
-		var b beers.Service
-		r.Get("/", beers.List(&b))
-		r.Post("/", beers.Create(&b))
-		r.Get("/{beerID}", beers.Get(&b))
-		r.Get("/{beerID}/boxprice", beers.BoxPrice(&b))
+		b := beers.NewService(a.Beers, a.Rates, a.Rounding)
+		r.Get("/", beers.List(b))
+		r.Post("/", beers.Create(b))
+		r.Post("/import", beers.Import(b))
+		r.Get("/export", beers.Export(b))
+		r.Get("/{beerID}", beers.Get(b))
+		r.Put("/{beerID}", beers.Update(b))
+		r.Patch("/{beerID}", beers.Patch(b))
+		r.Delete("/{beerID}", beers.Delete(b, a.AdminToken))
+		r.Post("/{beerID}/restore", beers.Restore(b))
+		r.Get("/{beerID}/boxprice", beers.BoxPrice(b))
 	})
+
+	r.Get("/rates", rates.List(a.RatesHistory))
+	r.Get("/currencies", refdata.ListCurrencies)
+	r.Get("/countries", refdata.ListCountries)
 }
 
 func basePingHandler(w http.ResponseWriter, _ *http.Request) {
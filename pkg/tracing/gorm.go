@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"errors"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin starts a client span per create, query, update, delete, row and raw operation, child of the span on
+// the statement context, so the queries made with WithContext join the request trace.
+type GormPlugin struct{}
+
+func (GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	registrations := []func() error{
+		func() error { return cb.Create().Before("gorm:create").Register("tracing:before_create", gormBefore("create")) },
+		func() error { return cb.Create().After("gorm:create").Register("tracing:after_create", gormAfter) },
+		func() error { return cb.Query().Before("gorm:query").Register("tracing:before_query", gormBefore("query")) },
+		func() error { return cb.Query().After("gorm:query").Register("tracing:after_query", gormAfter) },
+		func() error { return cb.Update().Before("gorm:update").Register("tracing:before_update", gormBefore("update")) },
+		func() error { return cb.Update().After("gorm:update").Register("tracing:after_update", gormAfter) },
+		func() error { return cb.Delete().Before("gorm:delete").Register("tracing:before_delete", gormBefore("delete")) },
+		func() error { return cb.Delete().After("gorm:delete").Register("tracing:after_delete", gormAfter) },
+		func() error { return cb.Row().Before("gorm:row").Register("tracing:before_row", gormBefore("row")) },
+		func() error { return cb.Row().After("gorm:row").Register("tracing:after_row", gormAfter) },
+		func() error { return cb.Raw().Before("gorm:raw").Register("tracing:before_raw", gormBefore("raw")) },
+		func() error { return cb.Raw().After("gorm:raw").Register("tracing:after_raw", gormAfter) },
+	}
+	for _, register := range registrations {
+		if err := register(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func gormBefore(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		_, span := Start(db.Statement.Context, "gorm."+operation, KindClient)
+		span.SetAttribute("db.system", db.Dialector.Name())
+		span.SetAttribute("db.operation", operation)
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func gormAfter(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(*Span)
+	if !ok {
+		return
+	}
+	if db.Statement.Table != "" {
+		span.SetAttribute("db.sql.table", db.Statement.Table)
+	}
+	span.SetAttribute("db.statement", db.Statement.SQL.String())
+	span.SetAttribute("db.rows_affected", db.RowsAffected)
+	if !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+	}
+	span.End()
+}
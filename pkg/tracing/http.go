@@ -0,0 +1,46 @@
+package tracing
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Middleware starts a server span per request, child of the incoming traceparent, and names it after the chi route
+// pattern once the request is routed, the sampled spans are exported by p. It must be used on the chi router.
+func Middleware(p *Provider) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := Start(Extract(ContextWithProvider(r.Context(), p), r.Header), r.Method, KindServer)
+			defer span.End()
+			span.SetAttribute("http.method", r.Method)
+			span.SetAttribute("http.target", r.URL.Path)
+			if id := middleware.GetReqID(r.Context()); id != "" {
+				span.SetAttribute("http.request_id", id)
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				span.SetName(r.Method + " " + rctx.RoutePattern())
+				span.SetAttribute("http.route", rctx.RoutePattern())
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttribute("http.status_code", status)
+			if status >= http.StatusInternalServerError {
+				span.RecordError(errorStatus(status))
+			}
+		})
+	}
+}
+
+type errorStatus int
+
+func (s errorStatus) Error() string {
+	return http.StatusText(int(s))
+}
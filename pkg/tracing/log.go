@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"context"
+	"go.uber.org/zap"
+)
+
+// LogFields answers the trace and span ids of ctx as zap fields, none when ctx isn't traced.
+func LogFields(ctx context.Context) []zap.Field {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return []zap.Field{zap.String("traceId", sc.TraceID.String()), zap.String("spanId", sc.SpanID.String())}
+}
+
+type loggerKey struct{}
+
+// ContextWithLogger makes logger the one answered by Logger on the returned context.
+func ContextWithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Logger answers the sugared logger of ctx with its trace and span ids, a no-op one when ctx has no logger.
+func Logger(ctx context.Context) *zap.SugaredLogger {
+	logger, _ := ctx.Value(loggerKey{}).(*zap.Logger)
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return logger.Sugar()
+	}
+	return logger.Sugar().With("traceId", sc.TraceID.String(), "spanId", sc.SpanID.String())
+}
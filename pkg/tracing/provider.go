@@ -0,0 +1,158 @@
+package tracing
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	queueSize     = 2048
+	maxBatchSize  = 512
+	flushInterval = 5 * time.Second
+)
+
+// Exporter sends batches of ended spans to their backend.
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+}
+
+// Provider exports the sampled spans in batches from a background goroutine. Spans ended while its queue is full
+// are dropped rather than slowing the requests down. Spans are started with the provider of their context, so each
+// app of a process exports its own.
+type Provider struct {
+	exporter    Exporter
+	sampleRatio float64
+	logger      *zap.Logger
+	queue       chan SpanData
+	flush       chan chan struct{}
+	stop        chan struct{}
+	done        chan struct{}
+	stopOnce    sync.Once
+	dropped     uint64
+}
+
+type providerKey struct{}
+
+// NewProvider starts exporting with exporter, sampling sampleRatio (between 0 and 1) of the new traces. The failed
+// exports are logged on logger.
+func NewProvider(exporter Exporter, sampleRatio float64, logger *zap.Logger) *Provider {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	p := &Provider{
+		exporter:    exporter,
+		sampleRatio: sampleRatio,
+		logger:      logger,
+		queue:       make(chan SpanData, queueSize),
+		flush:       make(chan chan struct{}),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// ContextWithProvider makes p the provider of the traces started on the returned context, nil stops sampling them.
+// Trace ids are propagated regardless.
+func ContextWithProvider(ctx context.Context, p *Provider) context.Context {
+	return context.WithValue(ctx, providerKey{}, p)
+}
+
+func providerFromContext(ctx context.Context) *Provider {
+	p, _ := ctx.Value(providerKey{}).(*Provider)
+	return p
+}
+
+// Dropped answers the spans lost because the queue was full.
+func (p *Provider) Dropped() uint64 {
+	return atomic.LoadUint64(&p.dropped)
+}
+
+func (p *Provider) enqueue(span SpanData) {
+	select {
+	case p.queue <- span:
+	default:
+		atomic.AddUint64(&p.dropped, 1)
+	}
+}
+
+// ForceFlush exports the queued spans, waiting for them until ctx is done.
+func (p *Provider) ForceFlush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	select {
+	case p.flush <- flushed:
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown exports the queued spans and stops, waiting for them until ctx is done.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+	})
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Provider) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	batch := make([]SpanData, 0, maxBatchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := p.exporter.Export(context.Background(), batch); err != nil {
+			p.logger.Sugar().Error("cannot export ", len(batch), " spans ", err)
+		}
+		batch = make([]SpanData, 0, maxBatchSize)
+	}
+	drain := func() {
+		for {
+			select {
+			case span := <-p.queue:
+				batch = append(batch, span)
+				if len(batch) == maxBatchSize {
+					export()
+				}
+			default:
+				export()
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case span := <-p.queue:
+			batch = append(batch, span)
+			if len(batch) == maxBatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case flushed := <-p.flush:
+			drain()
+			close(flushed)
+		case <-p.stop:
+			drain()
+			return
+		}
+	}
+}
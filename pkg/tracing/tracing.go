@@ -0,0 +1,238 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+	"sync"
+	"time"
+)
+
+// Kind is the role of a span, valued as in OTLP.
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+)
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+// SpanContext identifies a span across processes, only Sampled spans are exported.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// SpanData is an ended span as exporters get it, Error is empty for successful spans.
+type SpanData struct {
+	Name         string
+	Kind         Kind
+	TraceID      TraceID
+	SpanID       SpanID
+	ParentSpanID SpanID
+	Start        time.Time
+	End          time.Time
+	Attributes   []Attribute
+	Error        string
+}
+
+// Span is an operation in progress, it is safe to use from many goroutines.
+type Span struct {
+	mu       sync.Mutex
+	data     SpanData
+	sc       SpanContext
+	ended    bool
+	provider *Provider
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// Start begins a span child of the one in ctx, or of the remote parent extracted from a traceparent. Without
+// parents it starts a trace, sampled according to the SampleRatio of the provider of ctx. The span is exported by
+// the provider of its parent span, or else of ctx.
+func Start(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+	p := providerFromContext(ctx)
+	if parentSpan := SpanFromContext(ctx); parentSpan != nil {
+		p = parentSpan.provider
+	}
+	sc := SpanContext{TraceID: parent.TraceID, SpanID: newSpanID(), Sampled: parent.Sampled}
+	if !parent.IsValid() {
+		sc.TraceID, sc.Sampled = newTraceID(), sample(p)
+	}
+	span := &Span{
+		provider: p,
+		sc:       sc,
+		data: SpanData{
+			Name:         name,
+			Kind:         kind,
+			TraceID:      sc.TraceID,
+			SpanID:       sc.SpanID,
+			ParentSpanID: parent.SpanID,
+			Start:        time.Now(),
+		},
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SpanFromContext answers the span started on ctx, nil when there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// SpanContextFromContext answers the context of the current span, or of the remote parent when no span started.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// ContextWithRemote makes sc the parent of the spans started on the returned context.
+func ContextWithRemote(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Detach answers a context with the values of ctx, such as its span, tracing provider and logger, but never done.
+// The work shared by several requests runs on it, so it keeps the trace of the request that started it but not
+// its cancellation.
+func Detach(ctx context.Context) context.Context {
+	return detached{parent: ctx}
+}
+
+type detached struct {
+	parent context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (detached) Err() error {
+	return nil
+}
+
+func (d detached) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
+
+func (s *Span) SpanContext() SpanContext {
+	return s.sc
+}
+
+func (s *Span) SetName(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Name = name
+}
+
+// SetAttribute records key, value should be a string, bool, int, int64 or float64.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.data.Attributes {
+		if s.data.Attributes[i].Key == key {
+			s.data.Attributes[i].Value = value
+			return
+		}
+	}
+	s.data.Attributes = append(s.data.Attributes, Attribute{Key: key, Value: value})
+}
+
+// RecordError marks the span as failed, nil errors are ignored.
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Error = err.Error()
+}
+
+// End finishes the span and hands it to its provider when it is sampled, later calls do nothing.
+func (s *Span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	data.Attributes = append([]Attribute(nil), s.data.Attributes...)
+	s.mu.Unlock()
+	if s.sc.Sampled && s.provider != nil {
+		s.provider.enqueue(data)
+	}
+}
+
+func sample(p *Provider) bool {
+	if p == nil {
+		return false
+	}
+	if p.sampleRatio >= 1 {
+		return true
+	}
+	var b [8]byte
+	rand.Read(b[:])
+	n := uint64(0)
+	for _, v := range b {
+		n = n<<8 | uint64(v)
+	}
+	return float64(n)/math.MaxUint64 < p.sampleRatio
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,262 @@
+package tracing_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rgraterol/beers-api/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+)
+
+const traceparentMock = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+type recorderExporter struct {
+	mu    sync.Mutex
+	spans []tracing.SpanData
+}
+
+func (e *recorderExporter) Export(_ context.Context, spans []tracing.SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+// record exports the spans of the test to a recorder, flushed by the returned function.
+func record(t *testing.T) (*tracing.Provider, *recorderExporter, func()) {
+	exporter := &recorderExporter{}
+	provider := tracing.NewProvider(exporter, 1, nil)
+	t.Cleanup(func() {
+		provider.Shutdown(context.Background())
+	})
+	return provider, exporter, func() {
+		assert.Nil(t, provider.ForceFlush(context.Background()))
+	}
+}
+
+func TestTraceparentRoundTrip(t *testing.T) {
+	// Given
+	// When
+	sc, ok := tracing.ParseTraceparent(traceparentMock)
+	// Then
+	assert.True(t, ok)
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.Equal(t, traceparentMock, tracing.FormatTraceparent(sc))
+}
+
+func TestTraceparentInvalid(t *testing.T) {
+	values := []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+	}
+	for _, value := range values {
+		_, ok := tracing.ParseTraceparent(value)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestStartChildOfRemoteParent(t *testing.T) {
+	// Given
+	provider, exporter, flush := record(t)
+	header := http.Header{}
+	header.Set(tracing.TraceparentHeader, traceparentMock)
+	ctx := tracing.Extract(tracing.ContextWithProvider(context.Background(), provider), header)
+	// When
+	ctx, parent := tracing.Start(ctx, "parent", tracing.KindServer)
+	_, child := tracing.Start(ctx, "child", tracing.KindInternal)
+	child.RecordError(errors.New("boom"))
+	child.End()
+	parent.End()
+	parent.End()
+	flush()
+	// Then
+	assert.Len(t, exporter.spans, 2)
+	assert.Equal(t, "child", exporter.spans[0].Name)
+	assert.Equal(t, "boom", exporter.spans[0].Error)
+	assert.Equal(t, parent.SpanContext().SpanID, exporter.spans[0].ParentSpanID)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", exporter.spans[1].TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", exporter.spans[1].ParentSpanID.String())
+}
+
+func TestNotSampledParentIsNotExported(t *testing.T) {
+	// Given
+	provider, exporter, flush := record(t)
+	header := http.Header{}
+	header.Set(tracing.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+	// When
+	ctx := tracing.ContextWithProvider(context.Background(), provider)
+	ctx, span := tracing.Start(tracing.Extract(ctx, header), "parent", tracing.KindServer)
+	span.End()
+	flush()
+	out := http.Header{}
+	tracing.Inject(ctx, out)
+	// Then
+	assert.Empty(t, exporter.spans)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+span.SpanContext().SpanID.String()+"-00",
+		out.Get(tracing.TraceparentHeader))
+}
+
+func TestMiddlewareNamesSpanByRoute(t *testing.T) {
+	// Given
+	provider, exporter, flush := record(t)
+	var inner tracing.SpanContext
+	r := chi.NewRouter()
+	r.Use(tracing.Middleware(provider))
+	r.Get("/beers/{beerID}/boxprice", func(w http.ResponseWriter, r *http.Request) {
+		inner = tracing.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/beers/1/boxprice", nil)
+	req.Header.Set(tracing.TraceparentHeader, traceparentMock)
+	// When
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	flush()
+	// Then
+	assert.Len(t, exporter.spans, 1)
+	span := exporter.spans[0]
+	assert.Equal(t, "GET /beers/{beerID}/boxprice", span.Name)
+	assert.Equal(t, tracing.KindServer, span.Kind)
+	assert.Equal(t, inner.SpanID, span.SpanID)
+	assert.Equal(t, "00f067aa0ba902b7", span.ParentSpanID.String())
+	assert.Equal(t, "Bad Gateway", span.Error)
+	assert.Contains(t, span.Attributes, tracing.Attribute{Key: "http.status_code", Value: http.StatusBadGateway})
+}
+
+func TestGormPluginJoinsTheContextTrace(t *testing.T) {
+	// Given
+	provider, exporter, flush := record(t)
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, db.Use(tracing.GormPlugin{}))
+	type brewery struct {
+		ID   int64
+		Name string
+	}
+	assert.Nil(t, db.AutoMigrate(&brewery{}))
+	ctx, parent := tracing.Start(tracing.ContextWithProvider(context.Background(), provider), "GET /breweries/{id}",
+		tracing.KindServer)
+	// When
+	var found brewery
+	err = db.WithContext(ctx).First(&found, 7).Error
+	parent.End()
+	flush()
+	// Then
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	var query tracing.SpanData
+	for _, span := range exporter.spans {
+		if span.Name == "gorm.query" {
+			query = span
+		}
+	}
+	assert.Equal(t, parent.SpanContext().TraceID, query.TraceID)
+	assert.Equal(t, parent.SpanContext().SpanID, query.ParentSpanID)
+	assert.Empty(t, query.Error)
+	assert.Contains(t, query.Attributes, tracing.Attribute{Key: "db.sql.table", Value: "breweries"})
+}
+
+func TestSpansOfEachProvider(t *testing.T) {
+	// Given
+	first, firstExporter, flushFirst := record(t)
+	second, secondExporter, flushSecond := record(t)
+	// When
+	ctx, span := tracing.Start(tracing.ContextWithProvider(context.Background(), first), "first", tracing.KindServer)
+	_, child := tracing.Start(tracing.ContextWithProvider(ctx, second), "child", tracing.KindInternal)
+	child.End()
+	span.End()
+	_, other := tracing.Start(tracing.ContextWithProvider(context.Background(), second), "second", tracing.KindServer)
+	other.End()
+	_, unsampled := tracing.Start(context.Background(), "none", tracing.KindServer)
+	unsampled.End()
+	flushFirst()
+	flushSecond()
+	// Then
+	assert.Len(t, firstExporter.spans, 2)
+	assert.Equal(t, "child", firstExporter.spans[0].Name)
+	assert.Len(t, secondExporter.spans, 1)
+	assert.Equal(t, "second", secondExporter.spans[0].Name)
+	assert.False(t, unsampled.SpanContext().Sampled)
+}
+
+func TestDetachKeepsTheValuesButNotTheCancellation(t *testing.T) {
+	// Given
+	ctx, span := tracing.Start(context.Background(), "parent", tracing.KindServer)
+	ctx, cancel := context.WithCancel(ctx)
+	// When
+	cancel()
+	detached := tracing.Detach(ctx)
+	// Then
+	assert.Nil(t, detached.Err())
+	assert.Equal(t, span, tracing.SpanFromContext(detached))
+}
+
+func TestOTLPExporter(t *testing.T) {
+	// Given
+	var path string
+	var body map[string]interface{}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		data, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+	}))
+	defer collector.Close()
+	exporter := tracing.NewOTLPExporter(collector.URL+"/", "beers-api", time.Second)
+	sc, _ := tracing.ParseTraceparent(traceparentMock)
+	start := time.Unix(1644000000, 0)
+	// When
+	err := exporter.Export(context.Background(), []tracing.SpanData{{
+		Name:       "HTTP GET",
+		Kind:       tracing.KindClient,
+		TraceID:    sc.TraceID,
+		SpanID:     sc.SpanID,
+		Start:      start,
+		End:        start.Add(time.Millisecond),
+		Attributes: []tracing.Attribute{{Key: "http.status_code", Value: 200}},
+		Error:      "timeout",
+	}})
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "/v1/traces", path)
+	resource := body["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	scope := resource["scopeSpans"].([]interface{})[0].(map[string]interface{})
+	span := scope["spans"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span["traceId"])
+	assert.Equal(t, "00f067aa0ba902b7", span["spanId"])
+	assert.Nil(t, span["parentSpanId"])
+	assert.Equal(t, float64(3), span["kind"])
+	assert.Equal(t, "1644000000000000000", span["startTimeUnixNano"])
+	assert.Equal(t, "1644000000001000000", span["endTimeUnixNano"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"key": "http.status_code", "value": map[string]interface{}{"intValue": "200"},
+	}}, span["attributes"])
+	assert.Equal(t, map[string]interface{}{"code": float64(2), "message": "timeout"}, span["status"])
+}
+
+func TestOTLPExporterCollectorError(t *testing.T) {
+	// Given
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+	exporter := tracing.NewOTLPExporter(collector.URL, "beers-api", time.Second)
+	// When
+	err := exporter.Export(context.Background(), []tracing.SpanData{{Name: "gorm.query"}})
+	// Then
+	assert.EqualError(t, err, "the collector answered 503: overloaded")
+}
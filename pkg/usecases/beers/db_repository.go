@@ -0,0 +1,288 @@
+package beers
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+)
+
+const mysqlDuplicateEntry = 1062
+
+// mysqlConstraintErrors are the MySQL errors of rows refused by the schema: a null column, a foreign key on both
+// sides and a check constraint.
+var mysqlConstraintErrors = map[uint16]bool{1048: true, 1451: true, 1452: true, 3819: true}
+
+// updatableFields are the columns written by Update, selected explicitly so zero values are persisted too.
+var updatableFields = []string{"Name", "Brewery", "Country", "Price", "Currency"}
+
+// DBRepository keeps the beers on the beers table of MySQL, or SQLite on the tests.
+type DBRepository struct {
+	DB *gorm.DB
+}
+
+func NewDBRepository(g *gorm.DB) *DBRepository {
+	return &DBRepository{DB: g}
+}
+
+func (r *DBRepository) Count(ctx context.Context, params *BeerListParameters) (int64, error) {
+	var total int64
+	err := r.filterBeers(ctx, params).Count(&total).Error
+	return total, translateError(err)
+}
+
+func (r *DBRepository) Find(ctx context.Context, params *BeerListParameters, limit int) ([]Beer, error) {
+	query := r.filterBeers(ctx, params)
+	if params.Cursor != nil {
+		condition, args := params.Cursor.keysetCondition()
+		query = query.Where(condition, args...)
+	} else {
+		query = query.Offset(params.Offset)
+	}
+	var beers []Beer
+	err := query.Order(orderBy(params.Sort, params.Order)).Limit(limit).Find(&beers).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return beers, nil
+}
+
+// Each iterates the DB rows instead of loading every beer in memory.
+func (r *DBRepository) Each(ctx context.Context, params *BeerListParameters, fn func(b *Beer) error) error {
+	rows, err := r.filterBeers(ctx, params).Order(orderBy(params.Sort, params.Order)).Rows()
+	if err != nil {
+		return translateError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b Beer
+		if err = r.DB.ScanRows(rows, &b); err != nil {
+			return err
+		}
+		if err = fn(&b); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *DBRepository) Get(ctx context.Context, id int) (*Beer, error) {
+	var b Beer
+	if err := r.DB.WithContext(ctx).First(&b, id).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &b, nil
+}
+
+func (r *DBRepository) Create(ctx context.Context, b *Beer) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkDuplicated(tx, b); err != nil {
+			return err
+		}
+		return tx.Create(b).Error
+	})
+	return translateError(err)
+}
+
+func (r *DBRepository) Update(ctx context.Context, b *Beer) error {
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkDuplicated(tx, b); err != nil {
+			return err
+		}
+		return tx.Model(b).Select(updatableFields).Updates(b).Error
+	})
+	return translateError(err)
+}
+
+func (r *DBRepository) Delete(ctx context.Context, id int, purge bool) error {
+	query := r.DB.WithContext(ctx)
+	if purge {
+		query = query.Unscoped()
+	}
+	trx := query.Delete(&Beer{}, id)
+	if trx.Error != nil {
+		return translateError(trx.Error)
+	}
+	if trx.RowsAffected == 0 {
+		return NotFoundError
+	}
+	return nil
+}
+
+func (r *DBRepository) Restore(ctx context.Context, id int) (*Beer, error) {
+	var b Beer
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().First(&b, id).Error; err != nil {
+			return err
+		}
+		if !b.DeletedAt.Valid {
+			return nil
+		}
+		if err := checkDuplicated(tx, &b); err != nil {
+			return err
+		}
+		b.DeletedAt = gorm.DeletedAt{}
+		return tx.Unscoped().Model(&b).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &b, nil
+}
+
+// Import checks the beers against the DB and creates the free ones in batches, inside a single transaction.
+func (r *DBRepository) Import(ctx context.Context, beers []*Beer, dryRun bool) ([]bool, error) {
+	created := make([]bool, len(beers))
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := existingBeerKeys(tx, beers)
+		if err != nil {
+			return err
+		}
+		var toCreate []Beer
+		var toCreateIndexes []int
+		for i, b := range beers {
+			if existing[keyOf(b)] {
+				continue
+			}
+			existing[keyOf(b)] = true
+			created[i] = true
+			toCreate = append(toCreate, *b)
+			toCreateIndexes = append(toCreateIndexes, i)
+		}
+		if dryRun || len(toCreate) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(toCreate, importBatchSize).Error; err != nil {
+			return err
+		}
+		for i, b := range toCreate {
+			beers[toCreateIndexes[i]].ID = b.ID
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return created, nil
+}
+
+// filterBeers builds a fresh query with the filters of params, leaving paging and ordering to the caller.
+func (r *DBRepository) filterBeers(ctx context.Context, params *BeerListParameters) *gorm.DB {
+	query := r.DB.WithContext(ctx).Model(&Beer{})
+	if params.IncludeDeleted {
+		query = query.Unscoped()
+	}
+	if params.Country != "" {
+		query = query.Where("country = ?", params.Country)
+	}
+	if params.Brewery != "" {
+		query = query.Where("brewery = ?", params.Brewery)
+	}
+	if params.Currency != "" {
+		query = query.Where("currency = ?", params.Currency)
+	}
+	if params.MinPrice != nil {
+		query = query.Where("price >= ?", *params.MinPrice)
+	}
+	if params.MaxPrice != nil {
+		query = query.Where("price <= ?", *params.MaxPrice)
+	}
+	return query
+}
+
+func orderBy(sort string, order string) string {
+	if sort == "" {
+		sort = defaultSort
+	}
+	if order == "" {
+		order = orderAsc
+	}
+	clause := fmt.Sprintf("%s %s", sortableColumns[sort], order)
+	if sort != defaultSort {
+		clause += fmt.Sprintf(", id %s", order)
+	}
+	return clause
+}
+
+// checkDuplicated looks for another live beer holding the same name, brewery and country. It answers the common
+// case early, the unique index on the live key still refuses the concurrent writes racing past it.
+func checkDuplicated(tx *gorm.DB, b *Beer) error {
+	var count int64
+	trx := tx.Model(&Beer{}).
+		Where("name = ? AND brewery = ? AND country = ? AND id <> ?", b.Name, b.Brewery, b.Country, b.ID).
+		Count(&count)
+	if trx.Error != nil {
+		return trx.Error
+	}
+	if count > 0 {
+		return DuplicatedError
+	}
+	return nil
+}
+
+// existingBeerKeys finds which of the beers already have a live beer on the DB.
+func existingBeerKeys(tx *gorm.DB, beers []*Beer) (map[beerKey]bool, error) {
+	names := make(map[string]bool)
+	for _, b := range beers {
+		names[b.Name] = true
+	}
+	var chunk []string
+	keys := make(map[beerKey]bool)
+	flush := func() error {
+		var found []Beer
+		trx := tx.Select("name", "brewery", "country").Where("name IN ?", chunk).Find(&found)
+		if trx.Error != nil {
+			return trx.Error
+		}
+		for i := range found {
+			keys[keyOf(&found[i])] = true
+		}
+		chunk = chunk[:0]
+		return nil
+	}
+	for name := range names {
+		chunk = append(chunk, name)
+		if len(chunk) == importBatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(chunk) > 0 {
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// translateError turns the GORM and driver errors into the typed errors of BeerRepository. Unique violations keep
+// the driver message, so they can still be logged.
+func translateError(err error) error {
+	if err == nil || err == DuplicatedError {
+		return err
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NotFoundError
+	}
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		if mysqlErr.Number == mysqlDuplicateEntry {
+			return errors.Wrap(DuplicatedError, err.Error())
+		}
+		if mysqlConstraintErrors[mysqlErr.Number] {
+			return &ConstraintError{Err: err}
+		}
+	}
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrConstraint {
+		if sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey {
+			return errors.Wrap(DuplicatedError, err.Error())
+		}
+		return &ConstraintError{Err: err}
+	}
+	return err
+}
@@ -3,27 +3,155 @@ package beers
 import (
 	"gorm.io/gorm"
 	"time"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/validation"
 )
 
 type Beer struct {
 	ID        int64          `json:"id" gorm:"uniqueIndex,primaryKey"`
-	Name      string         `json:"name" gorm:"index:idx_name_brewery_country,unique"`
-	Brewery   string         `json:"brewery" gorm:"index" gorm:"index:idx_name_brewery_country,unique"`
-	Country   string         `json:"country" gorm:"index,index:idx_name_brewery_country,unique"`
-	Price     float64        `json:"price"`
+	Name      string         `json:"name" gorm:"index:idx_name_brewery_country;uniqueIndex:idx_beers_live_key"`
+	Brewery   string         `json:"brewery" gorm:"index;index:idx_name_brewery_country;uniqueIndex:idx_beers_live_key"`
+	Country   string         `json:"country" gorm:"index;index:idx_name_brewery_country;uniqueIndex:idx_beers_live_key"`
+	Price     money.Decimal  `json:"price"`
 	Currency  string         `json:"currency"`
 	UpdatedAt time.Time      `json:"-"`
 	CreatedAt time.Time      `json:"-"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	// Live is generated by the DB, 1 for the live beers and NULL for the deleted ones. NULLs never collide on the
+	// unique key, so the soft deleted beers release their name, brewery and country.
+	Live *int8 `json:"-" gorm:"->;type:tinyint GENERATED ALWAYS AS (CASE WHEN deleted_at IS NULL THEN 1 END) STORED;uniqueIndex:idx_beers_live_key"`
+}
+
+// BeerPatch holds the fields of a partial update, nil fields are left untouched.
+type BeerPatch struct {
+	Name     *string        `json:"name"`
+	Brewery  *string        `json:"brewery"`
+	Country  *string        `json:"country"`
+	Price    *money.Decimal `json:"price"`
+	Currency *string        `json:"currency"`
 }
 
+func (p *BeerPatch) apply(b *Beer) {
+	if p.Name != nil {
+		b.Name = *p.Name
+	}
+	if p.Brewery != nil {
+		b.Brewery = *p.Brewery
+	}
+	if p.Country != nil {
+		b.Country = *p.Country
+	}
+	if p.Price != nil {
+		b.Price = *p.Price
+	}
+	if p.Currency != nil {
+		b.Currency = *p.Currency
+	}
+}
+
+// BeerBox is the price of a box of beers in the target currency, rounded to its minor units.
 type BeerBox struct {
-	Price  float64           `json:"price"`
+	Price  money.Money       `json:"price"`
 	Target BeerBoxParameters `json:"target"`
 	Beer   Beer              `json:"beer"`
+	Rate   *BeerBoxRate      `json:"rate,omitempty"`
+}
+
+// BeerBoxRate tells where the exchange rates of a conversion came from and when they were published. Value is
+// the rate applied from the beer currency to the target one.
+type BeerBoxRate struct {
+	Source    string        `json:"source"`
+	Timestamp time.Time     `json:"timestamp"`
+	Value     money.Decimal `json:"value"`
+	Date      string        `json:"date,omitempty"`
+}
+
+type BeerListParameters struct {
+	IncludeDeleted bool           `json:"include_deleted"`
+	Country        string         `json:"country"`
+	Brewery        string         `json:"brewery"`
+	Currency       string         `json:"currency"`
+	MinPrice       *money.Decimal `json:"min_price"`
+	MaxPrice       *money.Decimal `json:"max_price"`
+	Sort           string         `json:"sort"`
+	Order          string         `json:"order"`
+	Limit          int            `json:"limit"`
+	Offset         int            `json:"offset"`
+	Cursor         *BeerCursor    `json:"-"`
+}
+
+// BeerPage is a slice of the beers matching a BeerListParameters.
+type BeerPage struct {
+	Results []Beer `json:"results"`
+	Paging  Paging `json:"paging"`
+}
+
+// BeerView is a beer as answered by the list and restore endpoints, DeletedAt is only set for soft deleted beers.
+// Beer never carries deleted_at on JSON, so request bodies cannot soft delete a beer.
+type BeerView struct {
+	Beer
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+func newBeerView(b *Beer) BeerView {
+	view := BeerView{Beer: *b}
+	if b.DeletedAt.Valid {
+		deletedAt := b.DeletedAt.Time
+		view.DeletedAt = &deletedAt
+	}
+	return view
+}
+
+// BeerViewPage is a BeerPage as answered by the list endpoint.
+type BeerViewPage struct {
+	Results []BeerView `json:"results"`
+	Paging  Paging     `json:"paging"`
+}
+
+func newBeerViewPage(page *BeerPage) BeerViewPage {
+	views := BeerViewPage{Results: make([]BeerView, len(page.Results)), Paging: page.Paging}
+	for i := range page.Results {
+		views.Results[i] = newBeerView(&page.Results[i])
+	}
+	return views
+}
+
+type Paging struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ImportRow is a parsed line of an import file, rows that failed parsing or validation carry their Error and,
+// when they were decoded, the Causes of every invalid field.
+type ImportRow struct {
+	Line   int
+	Beer   *Beer
+	Error  string
+	Causes []validation.FieldError
+}
+
+type ImportReport struct {
+	DryRun     bool              `json:"dry_run"`
+	Created    int               `json:"created"`
+	Duplicated int               `json:"duplicated"`
+	Invalid    int               `json:"invalid"`
+	Rows       []ImportRowResult `json:"rows"`
+}
+
+type ImportRowResult struct {
+	Line   int                     `json:"line"`
+	Status string                  `json:"status"`
+	ID     int64                   `json:"id,omitempty"`
+	Error  string                  `json:"error,omitempty"`
+	Causes []validation.FieldError `json:"causes,omitempty"`
 }
 
+// BeerBoxParameters are the target of a box price, Date (YYYY-MM-DD) prices it with the rates of a past day.
 type BeerBoxParameters struct {
 	Currency string `json:"currency"`
 	Quantity int64  `json:"quantity"`
+	Date     string `json:"date,omitempty"`
 }
@@ -0,0 +1,265 @@
+package beers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/refdata"
+	"github.com/rgraterol/beers-api/pkg/validation"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+	exportFormatJSON   = "json"
+	exportFlushEvery   = 100
+
+	deletedAtField         = "deleted_at"
+	convertedPriceField    = "converted_price"
+	convertedCurrencyField = "converted_currency"
+)
+
+// exportUnsupportedParams are the List paging params, an export streams every matching beer.
+var exportUnsupportedParams = []string{"limit", "offset", "cursor"}
+
+// exportFields are the columns an export can carry, in their default order. deleted_at is added when the deleted
+// beers are included, and the converted price when a target currency is requested.
+var exportFields = []string{"id", "name", "brewery", "country", "price", "currency"}
+
+// ExportParameters selects the beers to export and an optional currency to convert their prices to.
+type ExportParameters struct {
+	List           BeerListParameters
+	TargetCurrency string
+}
+
+// ExportRow is a streamed beer, ConvertedPrice is set when a target currency was requested and the beer
+// currency is known by the rates provider.
+type ExportRow struct {
+	Beer           Beer
+	ConvertedPrice *money.Decimal
+}
+
+func (row *ExportRow) value(field string, target string) interface{} {
+	switch field {
+	case "id":
+		return row.Beer.ID
+	case "name":
+		return row.Beer.Name
+	case "brewery":
+		return row.Beer.Brewery
+	case "country":
+		return row.Beer.Country
+	case "price":
+		return row.Beer.Price
+	case "currency":
+		return row.Beer.Currency
+	case deletedAtField:
+		if !row.Beer.DeletedAt.Valid {
+			return nil
+		}
+		return row.Beer.DeletedAt.Time.UTC().Format(time.RFC3339)
+	case convertedPriceField:
+		if row.ConvertedPrice == nil {
+			return nil
+		}
+		return *row.ConvertedPrice
+	case convertedCurrencyField:
+		return target
+	}
+	return nil
+}
+
+func decodeExportParams(r *http.Request) (*ExportParameters, string, []string, error) {
+	var v validation.Errors
+	for _, param := range exportUnsupportedParams {
+		if r.URL.Query().Get(param) != "" {
+			v.Check(validation.NewFieldError(param, validation.Unsupported, param + " is not supported, exports stream every matching beer"))
+		}
+	}
+	list, err := decodeBeerListParams(r)
+	if listErrs, ok := err.(*validation.Errors); ok {
+		for _, fe := range listErrs.Fields {
+			v.Check(fe)
+		}
+	} else if err != nil {
+		return nil, "", nil, err
+	}
+	targetCurrency := r.URL.Query().Get("target_currency")
+	if len(targetCurrency) != 0 {
+		if currency, ok := refdata.LookupCurrency(targetCurrency); ok {
+			targetCurrency = currency.Code
+		} else {
+			v.Check(validation.NewFieldError("target_currency", validation.ISO4217, "invalid target_currency"))
+		}
+	}
+	if err = v.Err(); err != nil {
+		return nil, "", nil, err
+	}
+	params := ExportParameters{List: *list, TargetCurrency: targetCurrency}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportFormatCSV
+	}
+	if format != exportFormatCSV && format != exportFormatNDJSON && format != exportFormatJSON {
+		return nil, "", nil, errors.New("invalid format, it must be csv, ndjson or json")
+	}
+
+	available := append([]string{}, exportFields...)
+	if params.List.IncludeDeleted {
+		available = append(available, deletedAtField)
+	}
+	if params.TargetCurrency != "" {
+		available = append(available, convertedPriceField, convertedCurrencyField)
+	}
+	fields := available
+	if raw := r.URL.Query().Get("fields"); raw != "" {
+		fields = nil
+		for _, field := range strings.Split(raw, ",") {
+			field = strings.TrimSpace(field)
+			if !containsField(available, field) {
+				return nil, "", nil, errors.New("invalid field " + field)
+			}
+			fields = append(fields, field)
+		}
+	}
+	return &params, format, fields, nil
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// exportWriter encodes the streamed rows on one of the export formats.
+type exportWriter interface {
+	begin() error
+	write(row *ExportRow) error
+	flush() error
+	end() error
+}
+
+func newExportWriter(format string, w io.Writer, fields []string, target string) exportWriter {
+	switch format {
+	case exportFormatNDJSON:
+		return &jsonExportWriter{w: w, fields: fields, target: target}
+	case exportFormatJSON:
+		return &jsonExportWriter{w: w, fields: fields, target: target, array: true}
+	}
+	return &csvExportWriter{w: csv.NewWriter(w), fields: fields, target: target}
+}
+
+func exportContentType(format string) string {
+	switch format {
+	case exportFormatNDJSON:
+		return ndjsonMediaType
+	case exportFormatJSON:
+		return "application/json"
+	}
+	return csvMediaType + "; charset=utf-8"
+}
+
+type csvExportWriter struct {
+	w      *csv.Writer
+	fields []string
+	target string
+}
+
+func (e *csvExportWriter) begin() error {
+	return e.w.Write(e.fields)
+}
+
+func (e *csvExportWriter) write(row *ExportRow) error {
+	record := make([]string, len(e.fields))
+	for i, field := range e.fields {
+		switch v := row.value(field, e.target).(type) {
+		case nil:
+		case string:
+			record[i] = v
+		case int64:
+			record[i] = strconv.FormatInt(v, 10)
+		case money.Decimal:
+			record[i] = v.String()
+		}
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvExportWriter) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExportWriter) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonExportWriter writes one object per line, or a JSON array when array is set. Objects are built by hand
+// to keep the requested field order.
+type jsonExportWriter struct {
+	w      io.Writer
+	fields []string
+	target string
+	array  bool
+	rows   int
+}
+
+func (e *jsonExportWriter) begin() error {
+	if e.array {
+		_, err := io.WriteString(e.w, "[")
+		return err
+	}
+	return nil
+}
+
+func (e *jsonExportWriter) write(row *ExportRow) error {
+	var sb strings.Builder
+	if e.array && e.rows > 0 {
+		sb.WriteString(",")
+	}
+	sb.WriteString("{")
+	for i, field := range e.fields {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		key, _ := json.Marshal(field)
+		value, err := json.Marshal(row.value(field, e.target))
+		if err != nil {
+			return err
+		}
+		sb.Write(key)
+		sb.WriteString(":")
+		sb.Write(value)
+	}
+	sb.WriteString("}")
+	if !e.array {
+		sb.WriteString("\n")
+	}
+	e.rows++
+	_, err := io.WriteString(e.w, sb.String())
+	return err
+}
+
+func (e *jsonExportWriter) flush() error {
+	return nil
+}
+
+func (e *jsonExportWriter) end() error {
+	if e.array {
+		_, err := io.WriteString(e.w, "]")
+		return err
+	}
+	return nil
+}
@@ -3,28 +3,44 @@ package beers
 import (
 	"encoding/json"
 	"errors"
-	"go.uber.org/zap"
-	"gorm.io/gorm"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/rgraterol/beers-api/pkg/auth"
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/refdata"
 	"github.com/rgraterol/beers-api/pkg/responses"
+	"github.com/rgraterol/beers-api/pkg/tracing"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
+	"github.com/rgraterol/beers-api/pkg/validation"
 )
 
 const (
 	defaultBeerIDParam  = "beerID"
 	defaultBeerQuantity = 6
 	currencySize        = 3
+	// bodyField names the errors about the request body as a whole
+	bodyField = "body"
 )
 func List(s Interface) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		beers, err := s.List()
+		params, err := decodeBeerListParams(r)
 		if err != nil {
+			tracing.Logger(r.Context()).Error(err)
 			responses.Error(w, err)
 			return
 		}
-		responses.OK(w, beers)
+		page, err := s.List(r.Context(), params)
+		if err != nil {
+			responses.Error(w, err)
+			return
+		}
+		if link := linkHeader(r, params, page); link != "" {
+			w.Header().Set("Link", link)
+		}
+		responses.OK(w, newBeerViewPage(page))
 	}
 }
 
@@ -32,13 +48,13 @@ func Create(s Interface) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		b, err := decodeAndValidateCreateBeerBody(r)
 		if err != nil {
-			zap.S().Error(err)
-			responses.BadRequest(w, err.Error())
+			tracing.Logger(r.Context()).Error(err)
+			responses.Error(w, err)
 			return
 		}
 
-		createdB, err := s.Create(b)
-		if err == DuplicatedError {
+		createdB, err := s.Create(r.Context(), b)
+		if errors.Is(err, DuplicatedError) {
 			responses.Duplicated(w, err.Error())
 			return
 		}
@@ -54,12 +70,12 @@ func Get(s Interface) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		beerId, err := strconv.Atoi(chi.URLParam(r, defaultBeerIDParam))
 		if err != nil {
-			zap.S().Error(err)
+			tracing.Logger(r.Context()).Error(err)
 			responses.BadRequest(w, "invalid " + defaultBeerIDParam)
 			return
 		}
-		beer, err := s.Get(beerId)
-		if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		beer, err := s.Get(r.Context(), beerId)
+		if err != nil && errors.Is(err, NotFoundError) {
 			responses.NotFound(w, "beer not found")
 			return
 		}
@@ -71,22 +87,218 @@ func Get(s Interface) func(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func Update(s Interface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		beerId, err := strconv.Atoi(chi.URLParam(r, defaultBeerIDParam))
+		if err != nil {
+			tracing.Logger(r.Context()).Error(err)
+			responses.BadRequest(w, "invalid " + defaultBeerIDParam)
+			return
+		}
+		b, err := decodeAndValidateCreateBeerBody(r)
+		if err != nil {
+			tracing.Logger(r.Context()).Error(err)
+			responses.Error(w, err)
+			return
+		}
+		updatedB, err := s.Update(r.Context(), beerId, b)
+		writeUpdateResponse(w, updatedB, err)
+	}
+}
+
+func Patch(s Interface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		beerId, err := strconv.Atoi(chi.URLParam(r, defaultBeerIDParam))
+		if err != nil {
+			tracing.Logger(r.Context()).Error(err)
+			responses.BadRequest(w, "invalid " + defaultBeerIDParam)
+			return
+		}
+		p, err := decodeAndValidatePatchBeerBody(r)
+		if err != nil {
+			tracing.Logger(r.Context()).Error(err)
+			responses.Error(w, err)
+			return
+		}
+		updatedB, err := s.Patch(r.Context(), beerId, p)
+		writeUpdateResponse(w, updatedB, err)
+	}
+}
+
+func writeUpdateResponse(w http.ResponseWriter, b *Beer, err error) {
+	if err != nil && errors.Is(err, NotFoundError) {
+		responses.NotFound(w, "beer not found")
+		return
+	}
+	if errors.Is(err, DuplicatedError) {
+		responses.Duplicated(w, err.Error())
+		return
+	}
+	if err != nil {
+		responses.Error(w, err)
+		return
+	}
+	responses.OK(w, b)
+}
+
+func Delete(s Interface, adminToken string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		beerId, err := strconv.Atoi(chi.URLParam(r, defaultBeerIDParam))
+		if err != nil {
+			tracing.Logger(r.Context()).Error(err)
+			responses.BadRequest(w, "invalid " + defaultBeerIDParam)
+			return
+		}
+		purge, err := parseBoolParam(r, "purge")
+		if err != nil {
+			tracing.Logger(r.Context()).Error(err)
+			responses.BadRequest(w, err.Error())
+			return
+		}
+		if purge && !auth.IsAdmin(r, adminToken) {
+			responses.Forbidden(w, "purge is only allowed for admins")
+			return
+		}
+		err = s.Delete(r.Context(), beerId, purge)
+		if err != nil && errors.Is(err, NotFoundError) {
+			responses.NotFound(w, "beer not found")
+			return
+		}
+		if err != nil {
+			responses.Error(w, err)
+			return
+		}
+		responses.NoContent(w)
+	}
+}
+
+func Restore(s Interface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		beerId, err := strconv.Atoi(chi.URLParam(r, defaultBeerIDParam))
+		if err != nil {
+			tracing.Logger(r.Context()).Error(err)
+			responses.BadRequest(w, "invalid " + defaultBeerIDParam)
+			return
+		}
+		b, err := s.Restore(r.Context(), beerId)
+		if err != nil {
+			writeUpdateResponse(w, nil, err)
+			return
+		}
+		responses.OK(w, newBeerView(b))
+	}
+}
+
+func Import(s Interface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dryRun, err := parseBoolParam(r, "dry_run")
+		if err != nil {
+			tracing.Logger(r.Context()).Error(err)
+			responses.BadRequest(w, err.Error())
+			return
+		}
+		rows, err := decodeImportBody(w, r)
+		if err == unsupportedImportError {
+			responses.UnsupportedMediaType(w, err.Error())
+			return
+		}
+		if err != nil {
+			tracing.Logger(r.Context()).Error(err)
+			responses.BadRequest(w, err.Error())
+			return
+		}
+		report, err := s.Import(r.Context(), rows, dryRun)
+		if err != nil {
+			responses.Error(w, err)
+			return
+		}
+		responses.OK(w, report)
+	}
+}
+
+func Export(s Interface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params, format, fields, err := decodeExportParams(r)
+		var fieldErrs *validation.Errors
+		if errors.As(err, &fieldErrs) {
+			responses.Error(w, err)
+			return
+		}
+		if err != nil {
+			tracing.Logger(r.Context()).Error(err)
+			responses.BadRequest(w, err.Error())
+			return
+		}
+
+		// The response starts with the first row, so errors found before it can still be answered properly.
+		var ew exportWriter
+		start := func() error {
+			w.Header().Set("Content-Type", exportContentType(format))
+			w.Header().Set("Content-Disposition", `attachment; filename="beers.` + format + `"`)
+			w.WriteHeader(http.StatusOK)
+			ew = newExportWriter(format, w, fields, params.TargetCurrency)
+			return ew.begin()
+		}
+		flusher, _ := w.(http.Flusher)
+		rows := 0
+		err = s.Export(r.Context(), params, func(row *ExportRow) error {
+			if ew == nil {
+				if err := start(); err != nil {
+					return err
+				}
+			}
+			if err := ew.write(row); err != nil {
+				return err
+			}
+			rows++
+			if rows%exportFlushEvery == 0 && flusher != nil {
+				if err := ew.flush(); err != nil {
+					return err
+				}
+				flusher.Flush()
+			}
+			return nil
+		})
+		if err != nil && ew == nil {
+			if err == invalidTargetCurrencyError {
+				responses.BadRequest(w, err.Error())
+				return
+			}
+			responses.Error(w, err)
+			return
+		}
+		if err != nil {
+			tracing.Logger(r.Context()).Error("export interrupted after " + strconv.Itoa(rows) + " rows", err)
+			return
+		}
+		if ew == nil {
+			if err = start(); err != nil {
+				tracing.Logger(r.Context()).Error(err)
+				return
+			}
+		}
+		if err = ew.end(); err != nil {
+			tracing.Logger(r.Context()).Error(err)
+		}
+	}
+}
+
 func BoxPrice(s Interface) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		beerId, err := strconv.Atoi(chi.URLParam(r, defaultBeerIDParam))
 		if err != nil {
-			zap.S().Error(err)
+			tracing.Logger(r.Context()).Error(err)
 			responses.BadRequest(w, "invalid " + defaultBeerIDParam)
 			return
 		}
 		boxParams, err := decodeBeerBoxPriceParams(r)
 		if err != nil {
-			zap.S().Error(err)
-			responses.BadRequest(w, err.Error())
+			tracing.Logger(r.Context()).Error(err)
+			responses.Error(w, err)
 			return
 		}
-		beerBox, err := s.BoxPrice(beerId, boxParams)
-		if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
+		beerBox, err := s.BoxPrice(r.Context(), beerId, boxParams)
+		if err != nil && errors.Is(err, NotFoundError) {
 			responses.NotFound(w, "beer not found")
 			return
 		}
@@ -103,34 +315,242 @@ func decodeAndValidateCreateBeerBody(r *http.Request) (*Beer, error) {
 	var b Beer
 	err := json.NewDecoder(r.Body).Decode(&b)
 	if err != nil {
-		return nil, err
+		return nil, validation.NewFieldError(bodyField, validation.Format, err.Error())
 	}
-	if b.Name == "" {
-		err = errors.New("name cannot be empty")
+	var v validation.Errors
+	validateBeer(&v, &b)
+	if err = v.Err(); err != nil {
 		return nil, err
 	}
-	if b.Price == 0  {
-		err = errors.New("price cannot be zero nor empty")
+	return &b, nil
+}
+
+// validateBeer collects on v the rules every stored beer must follow, normalising its currency and country.
+func validateBeer(v *validation.Errors, b *Beer) {
+	var err error
+	v.Check(validateName(b.Name))
+	v.Check(validatePrice(b.Price))
+	b.Currency, err = normaliseCurrency(b.Currency)
+	v.Check(err)
+	b.Country, err = normaliseCountry(b.Country)
+	v.Check(err)
+}
+
+func decodeAndValidatePatchBeerBody(r *http.Request) (*BeerPatch, error) {
+	var p BeerPatch
+	err := json.NewDecoder(r.Body).Decode(&p)
+	if err != nil {
+		return nil, validation.NewFieldError(bodyField, validation.Format, err.Error())
+	}
+	if p == (BeerPatch{}) {
+		return nil, validation.NewFieldError(bodyField, validation.Required, "patch body cannot be empty")
+	}
+	var v validation.Errors
+	if p.Name != nil {
+		v.Check(validateName(*p.Name))
+	}
+	if p.Price != nil {
+		v.Check(validatePrice(*p.Price))
+	}
+	if p.Currency != nil {
+		*p.Currency, err = normaliseCurrency(*p.Currency)
+		v.Check(err)
+	}
+	if p.Country != nil {
+		*p.Country, err = normaliseCountry(*p.Country)
+		v.Check(err)
+	}
+	if err = v.Err(); err != nil {
 		return nil, err
 	}
-	if b.Currency == "" || len(b.Currency) != currencySize {
-		err = errors.New("currency cannot be empty or different than 3 characters")
+	return &p, nil
+}
+
+func validateName(name string) error {
+	if name == "" {
+		return validation.NewFieldError("name", validation.Required, "name cannot be empty")
+	}
+	return nil
+}
+
+// validatePrice refuses the prices the DB would truncate, they are stored with money.Scale decimal places.
+func validatePrice(price money.Decimal) error {
+	if price.IsZero() {
+		return validation.NewFieldError("price", validation.Required, "price cannot be zero nor empty")
+	}
+	if price.Places() > money.Scale {
+		return validation.NewFieldError("price", validation.Format,
+			"price cannot have more than " + strconv.Itoa(money.Scale) + " decimal places")
+	}
+	return nil
+}
+
+// normaliseCurrency answers the ISO-4217 code of currency, "usd" becomes "USD".
+func normaliseCurrency(currency string) (string, error) {
+	if currency == "" {
+		return "", validation.NewFieldError("currency", validation.Required, "currency cannot be empty")
+	}
+	if len(currency) != currencySize {
+		return "", validation.NewFieldError("currency", validation.Length, "currency must be 3 characters long")
+	}
+	c, ok := refdata.LookupCurrency(currency)
+	if !ok {
+		return "", validation.NewFieldError("currency", validation.ISO4217,
+			"currency " + currency + " is not an ISO-4217 code")
+	}
+	return c.Code, nil
+}
+
+// normaliseCountry answers the ISO-3166 name of country, which can be given by name or code: "chile", "CL" and
+// "CHL" become "Chile". The country is optional.
+func normaliseCountry(country string) (string, error) {
+	if country == "" {
+		return "", nil
+	}
+	c, ok := refdata.LookupCountry(country)
+	if !ok {
+		return "", validation.NewFieldError("country", validation.ISO3166,
+			"country " + country + " is not an ISO-3166 country")
+	}
+	return c.Name, nil
+}
+
+// decodeBeerListParams checks every param, the invalid ones are answered together as validation errors.
+func decodeBeerListParams(r *http.Request) (*BeerListParameters, error) {
+	var v validation.Errors
+	var err error
+	query := r.URL.Query()
+	params := BeerListParameters{
+		Country:  query.Get("country"),
+		Brewery:  query.Get("brewery"),
+		Currency: query.Get("currency"),
+		Sort:     defaultSort,
+		Order:    orderAsc,
+		Limit:    defaultListLimit,
+	}
+	params.IncludeDeleted, err = parseBoolParam(r, "include_deleted")
+	v.Check(err)
+	if len(params.Currency) != 0 && len(params.Currency) != currencySize {
+		v.Check(validation.NewFieldError("currency", validation.Length, "invalid currency"))
+	}
+	// Known values are normalised like the stored beers, anything else is matched as sent
+	if c, ok := refdata.LookupCurrency(params.Currency); ok {
+		params.Currency = c.Code
+	}
+	if c, ok := refdata.LookupCountry(params.Country); ok {
+		params.Country = c.Name
+	}
+	params.MinPrice, err = parseDecimalParam(r, "min_price")
+	v.Check(err)
+	params.MaxPrice, err = parseDecimalParam(r, "max_price")
+	v.Check(err)
+	if params.MinPrice != nil && params.MaxPrice != nil && params.MinPrice.Cmp(*params.MaxPrice) > 0 {
+		v.Check(validation.NewFieldError("min_price", validation.Range, "min_price cannot be greater than max_price"))
+	}
+	if sort := query.Get("sort"); sort != "" {
+		if _, ok := sortableColumns[sort]; ok {
+			params.Sort = sort
+		} else {
+			v.Check(validation.NewFieldError("sort", validation.Format, "invalid sort"))
+		}
+	}
+	if order := query.Get("order"); order != "" {
+		if order == orderAsc || order == orderDesc {
+			params.Order = order
+		} else {
+			v.Check(validation.NewFieldError("order", validation.Format, "invalid order"))
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		message := "invalid limit, it must be between 1 and " + strconv.Itoa(maxListLimit)
+		if params.Limit, err = strconv.Atoi(limit); err != nil {
+			v.Check(validation.NewFieldError("limit", validation.Format, message))
+		} else if params.Limit < 1 || params.Limit > maxListLimit {
+			v.Check(validation.NewFieldError("limit", validation.Range, message))
+		}
+	}
+	if offset := query.Get("offset"); offset != "" {
+		if params.Offset, err = strconv.Atoi(offset); err != nil {
+			v.Check(validation.NewFieldError("offset", validation.Format, "invalid offset"))
+		} else if params.Offset < 0 {
+			v.Check(validation.NewFieldError("offset", validation.Range, "invalid offset"))
+		}
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		if query.Get("offset") != "" {
+			v.Check(validation.NewFieldError("cursor", validation.Unsupported, "cursor and offset cannot be used together"))
+		} else {
+			params.Cursor, err = decodeCursor(cursor, params.Sort, params.Order)
+			v.Check(err)
+		}
+	}
+	if err = v.Err(); err != nil {
 		return nil, err
 	}
-	return &b, err
+	return &params, nil
 }
 
-func decodeBeerBoxPriceParams(r *http.Request) (*BeerBoxParameters, error) {
-	q, err := strconv.Atoi(r.URL.Query().Get("quantity"))
+// parseDecimalParam reads an optional decimal query param, nil when it is absent.
+func parseDecimalParam(r *http.Request, name string) (*money.Decimal, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	d, err := money.NewFromString(v)
 	if err != nil {
-		q = defaultBeerQuantity
+		return nil, validation.NewFieldError(name, validation.Format, "invalid " + name)
+	}
+	return &d, nil
+}
+
+// parseBoolParam reads an optional boolean query param, false when it is absent.
+func parseBoolParam(r *http.Request, name string) (bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, validation.NewFieldError(name, validation.Format, "invalid " + name)
+	}
+	return b, nil
+}
+
+func decodeBeerBoxPriceParams(r *http.Request) (*BeerBoxParameters, error) {
+	var v validation.Errors
+	q := defaultBeerQuantity
+	if quantity := r.URL.Query().Get("quantity"); quantity != "" {
+		var err error
+		if q, err = strconv.Atoi(quantity); err != nil {
+			v.Check(validation.NewFieldError("quantity", validation.Format, "invalid quantity, it must be an integer"))
+		} else if q <= 0 {
+			v.Check(validation.NewFieldError("quantity", validation.Range, "quantity must be greater than 0"))
+		}
 	}
 	c := r.URL.Query().Get("currency")
-	if len(c) != 0 && len(c) != currencySize {
-		return nil, errors.New("invalid currency")
+	if len(c) != 0 {
+		currency, ok := refdata.LookupCurrency(c)
+		if ok {
+			c = currency.Code
+		} else {
+			v.Check(validation.NewFieldError("currency", validation.ISO4217, "invalid currency"))
+		}
+	}
+	date := r.URL.Query().Get("date")
+	if date != "" {
+		day, err := time.Parse(rates.DateLayout, date)
+		if err != nil {
+			v.Check(validation.NewFieldError("date", validation.Format, "invalid date, it must be YYYY-MM-DD"))
+		} else if day.After(time.Now().UTC()) {
+			v.Check(validation.NewFieldError("date", validation.Range, "date cannot be in the future"))
+		}
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
 	}
 	return &BeerBoxParameters{
 		Quantity: int64(q),
 		Currency: c,
+		Date:     date,
 	}, nil
-}
\ No newline at end of file
+}
@@ -3,15 +3,23 @@ package beers_test
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"gorm.io/gorm"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/pkg/auth"
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/responses"
 	"github.com/rgraterol/beers-api/pkg/usecases/beers"
+	"github.com/rgraterol/beers-api/pkg/validation"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -65,6 +73,25 @@ func TestCreateEmptyPrice400(t *testing.T) {
 	assert.Contains(t, "price cannot be zero nor empty", resp["message"])
 }
 
+func TestCreatePriceTooPrecise400(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Create(&ServiceMockError{})))
+	defer ts.Close()
+	body := `{"name":"Test","price":2.1234567,"currency":"USD"}`
+	//WHEN
+	res, _ := http.Post(ts.URL, "application/json", bytes.NewBufferString(body))
+	var resp struct {
+		Cause []validation.FieldError `json:"cause"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, []validation.FieldError{
+		{Field: "price", Rule: validation.Format, Message: "price cannot have more than 6 decimal places"},
+	}, resp.Cause)
+}
+
 func TestCreateEmptyCurrency400(t *testing.T) {
 	//GIVEN
 	ts := httptest.NewServer(http.HandlerFunc(beers.Create(&ServiceMockError{})))
@@ -82,7 +109,7 @@ func TestCreateEmptyCurrency400(t *testing.T) {
 	//THEN
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
-	assert.Contains(t, "currency cannot be empty or different than 3 characters", resp["message"])
+	assert.Equal(t, "currency cannot be empty", resp["message"])
 }
 
 func TestCreateCurrencyTooLong400(t *testing.T) {
@@ -103,7 +130,7 @@ func TestCreateCurrencyTooLong400(t *testing.T) {
 	//THEN
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
-	assert.Contains(t, "currency cannot be empty or different than 3 characters", resp["message"])
+	assert.Equal(t, "currency must be 3 characters long", resp["message"])
 }
 
 func TestCreateCurrencyTooShort400(t *testing.T) {
@@ -124,7 +151,129 @@ func TestCreateCurrencyTooShort400(t *testing.T) {
 	//THEN
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
-	assert.Contains(t, "currency cannot be empty or different than 3 characters", resp["message"])
+	assert.Equal(t, "currency must be 3 characters long", resp["message"])
+}
+
+func TestCreateUnknownCurrency400(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Create(&ServiceMockError{})))
+	defer ts.Close()
+	values := map[string]interface{}{
+		"name":"Test",
+		"price":1.2,
+		"currency": "XYZ",
+	}
+	body, err := json.Marshal(values)
+	assert.Nil(t, err)
+	//WHEN
+	res, _ := http.Post(ts.URL, "application/json", bytes.NewBuffer(body))
+	var resp map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "currency XYZ is not an ISO-4217 code", resp["message"])
+}
+
+func TestCreateUnknownCountry400(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Create(&ServiceMockError{})))
+	defer ts.Close()
+	values := map[string]interface{}{
+		"name":"Test",
+		"price":1.2,
+		"currency": "USD",
+		"country": "Narnia",
+	}
+	body, err := json.Marshal(values)
+	assert.Nil(t, err)
+	//WHEN
+	res, _ := http.Post(ts.URL, "application/json", bytes.NewBuffer(body))
+	var resp map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "country Narnia is not an ISO-3166 country", resp["message"])
+}
+
+func TestCreateEveryInvalidField400(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Create(&ServiceMockError{})))
+	defer ts.Close()
+	values := map[string]interface{}{
+		"country": "Narnia",
+	}
+	body, err := json.Marshal(values)
+	assert.Nil(t, err)
+	//WHEN
+	res, _ := http.Post(ts.URL, "application/json", bytes.NewBuffer(body))
+	var resp struct {
+		Message string                  `json:"message"`
+		Cause   []validation.FieldError `json:"cause"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "name cannot be empty", resp.Message)
+	assert.Equal(t, []validation.FieldError{
+		{Field: "name", Rule: validation.Required, Message: "name cannot be empty"},
+		{Field: "price", Rule: validation.Required, Message: "price cannot be zero nor empty"},
+		{Field: "currency", Rule: validation.Required, Message: "currency cannot be empty"},
+		{Field: "country", Rule: validation.ISO3166, Message: "country Narnia is not an ISO-3166 country"},
+	}, resp.Cause)
+}
+
+func TestCreateNormalisesCurrencyAndCountry201(t *testing.T) {
+	//GIVEN
+	var created *beers.Beer
+	ts := httptest.NewServer(http.HandlerFunc(beers.Create(&createSpy{onCreate: func(b *beers.Beer) {
+		created = b
+	}})))
+	defer ts.Close()
+	values := map[string]interface{}{
+		"name":"Test",
+		"price":1.2,
+		"currency": "usd",
+		"country": "chl",
+	}
+	body, err := json.Marshal(values)
+	assert.Nil(t, err)
+	//WHEN
+	res, _ := http.Post(ts.URL, "application/json", bytes.NewBuffer(body))
+	//THEN
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.NotNil(t, created)
+	assert.Equal(t, "USD", created.Currency)
+	assert.Equal(t, "Chile", created.Country)
+}
+
+func TestCreateIgnoresDeletedAt201(t *testing.T) {
+	//GIVEN
+	var created *beers.Beer
+	ts := httptest.NewServer(http.HandlerFunc(beers.Create(&createSpy{onCreate: func(b *beers.Beer) {
+		created = b
+	}})))
+	defer ts.Close()
+	values := map[string]interface{}{
+		"name":"Test",
+		"price":1.2,
+		"currency": "USD",
+		"deleted_at": "2021-06-01T00:00:00Z",
+	}
+	body, err := json.Marshal(values)
+	assert.Nil(t, err)
+	//WHEN
+	res, _ := http.Post(ts.URL, "application/json", bytes.NewBuffer(body))
+	var resp map[string]interface{}
+	err = json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.NotNil(t, created)
+	assert.False(t, created.DeletedAt.Valid)
+	assert.NotContains(t, resp, "deleted_at")
 }
 
 func TestCreateDuplicated409(t *testing.T) {
@@ -178,6 +327,37 @@ func TestList200(t *testing.T) {
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 }
 
+func TestListCSV200(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(responses.Negotiation(http.HandlerFunc(beers.List(&ServiceMockOk{}))))
+	defer ts.Close()
+	req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+	assert.Nil(t, err)
+	req.Header.Set("Accept", "text/csv")
+	//WHEN
+	res, err := http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/csv", res.Header.Get("Content-Type"))
+	assert.Equal(t, "id,name,brewery,country,price,currency\n1,test beer,,,0,\n", string(body))
+}
+
+func TestGetXML200(t *testing.T) {
+	//GIVEN
+	handler := responses.Negotiation(http.HandlerFunc(beers.Get(&ServiceMockOk{})))
+	req := buildRecorderWithContext("1", "/1")
+	req.Header.Set("Accept", "application/xml")
+	w := httptest.NewRecorder()
+	//WHEN
+	handler.ServeHTTP(w, req)
+	//THEN
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<response><id>1</id><name></name>")
+}
+
 func TestListError500(t *testing.T) {
 	//GIVEN
 	ts := httptest.NewServer(http.HandlerFunc(beers.List(&ServiceMockError{})))
@@ -188,6 +368,112 @@ func TestListError500(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
 }
 
+func TestListDeletedAt200(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.List(&deletedListMock{})))
+	defer ts.Close()
+	//WHEN
+	res, _ := http.Get(ts.URL + "?include_deleted=true")
+	var resp struct {
+		Results []map[string]interface{}
+	}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "2021-06-01T00:00:00Z", resp.Results[0]["deleted_at"])
+	assert.NotContains(t, resp.Results[1], "deleted_at")
+}
+
+func TestListInvalidIncludeDeleted400(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.List(&ServiceMockOk{})))
+	defer ts.Close()
+	//WHEN
+	res, _ := http.Get(ts.URL + "?include_deleted=maybe")
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "invalid include_deleted", resp["message"])
+}
+
+func TestListEveryInvalidParam400(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.List(&ServiceMockOk{})))
+	defer ts.Close()
+	//WHEN
+	res, _ := http.Get(ts.URL + "?currency=EU&min_price=10&max_price=5&sort=deleted_at&order=up&limit=0&offset=-1&cursor=abc")
+	var resp struct {
+		Message string                  `json:"message"`
+		Cause   []validation.FieldError `json:"cause"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "invalid currency", resp.Message)
+	assert.Equal(t, []validation.FieldError{
+		{Field: "currency", Rule: validation.Length, Message: "invalid currency"},
+		{Field: "min_price", Rule: validation.Range, Message: "min_price cannot be greater than max_price"},
+		{Field: "sort", Rule: validation.Format, Message: "invalid sort"},
+		{Field: "order", Rule: validation.Format, Message: "invalid order"},
+		{Field: "limit", Rule: validation.Range, Message: "invalid limit, it must be between 1 and 500"},
+		{Field: "offset", Rule: validation.Range, Message: "invalid offset"},
+		{Field: "cursor", Rule: validation.Unsupported, Message: "cursor and offset cannot be used together"},
+	}, resp.Cause)
+}
+
+func TestListPagingAndLinks200(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.List(&ServiceMockOk{})))
+	defer ts.Close()
+	//WHEN
+	res, _ := http.Get(ts.URL + "?limit=1&offset=1&country=Chile")
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	paging := resp["paging"].(map[string]interface{})
+	assert.Equal(t, float64(2), paging["total"])
+	assert.Equal(t, float64(1), paging["limit"])
+	assert.Equal(t, "next", paging["next_cursor"])
+	assert.Contains(t, res.Header.Get("Link"), `</?country=Chile&cursor=next&limit=1>; rel="next"`)
+	assert.Contains(t, res.Header.Get("Link"), `</?country=Chile&limit=1&offset=0>; rel="prev"`)
+}
+
+func TestListInvalidParams400(t *testing.T) {
+	cases := map[string]string{
+		"?limit=0":                  "invalid limit, it must be between 1 and 500",
+		"?limit=501":                "invalid limit, it must be between 1 and 500",
+		"?offset=-1":                "invalid offset",
+		"?sort=deleted_at":          "invalid sort",
+		"?order=up":                 "invalid order",
+		"?currency=EU":              "invalid currency",
+		"?min_price=abc":            "invalid min_price",
+		"?min_price=10&max_price=5": "min_price cannot be greater than max_price",
+		"?cursor=abc&offset=2":      "cursor and offset cannot be used together",
+		"?cursor=not-a-cursor":      "invalid cursor",
+		"?sort=name&cursor=" + rawCursor(`{"s":"name","o":"asc","v":5,"id":1}`):       "invalid cursor",
+		"?sort=price&cursor=" + rawCursor(`{"s":"price","o":"asc","v":"ten","id":1}`): "invalid cursor",
+		"?cursor=" + rawCursor(`{"s":"id","o":"asc","v":"1","id":1}`):                 "invalid cursor",
+	}
+	ts := httptest.NewServer(http.HandlerFunc(beers.List(&ServiceMockOk{})))
+	defer ts.Close()
+	for query, message := range cases {
+		//WHEN
+		res, _ := http.Get(ts.URL + query)
+		var resp map[string]interface{}
+		err := json.NewDecoder(res.Body).Decode(&resp)
+		//THEN
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+		assert.Equal(t, message, resp["message"], query)
+	}
+}
+
 func TestGet400(t *testing.T) {
 	///GIVEN
 	handler := beers.Get(&ServiceMockOk{})
@@ -239,6 +525,38 @@ func TestGet404(t *testing.T) {
 	assert.Equal(t,"beer not found", resp["message"])
 }
 
+func TestGetTimeout504(t *testing.T) {
+	///GIVEN
+	handler := beers.Get(&contextSpy{})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	req := buildRecorderWithContext("1", "/beers/1")
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, chi.RouteContext(req.Context()))))
+	res := w.Result()
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusGatewayTimeout, res.StatusCode)
+	assert.Equal(t, "Gateway Timeout", resp["message"])
+}
+
+func TestGetClientGone499(t *testing.T) {
+	///GIVEN
+	handler := beers.Get(&contextSpy{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := buildRecorderWithContext("1", "/beers/1")
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req.WithContext(context.WithValue(ctx, chi.RouteCtxKey, chi.RouteContext(req.Context()))))
+	//THEN
+	assert.Equal(t, responses.StatusClientClosedRequest, w.Code)
+}
+
 func TestBoxPriceInvalidBeerID400(t *testing.T) {
 	///GIVEN
 	handler := beers.BoxPrice(&ServiceMockOk{})
@@ -273,6 +591,104 @@ func TestBoxPriceInvalidCurrency400(t *testing.T) {
 	assert.Equal(t, "invalid currency", resp["message"])
 }
 
+func TestBoxPriceUnknownCurrency400(t *testing.T) {
+	///GIVEN
+	handler := beers.BoxPrice(&ServiceMockOk{})
+	req := buildRecorderWithContext("22", "/22?currency=XYZ")
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "invalid currency", resp["message"])
+}
+
+func TestBoxPriceEveryInvalidParam400(t *testing.T) {
+	///GIVEN
+	handler := beers.BoxPrice(&ServiceMockOk{})
+	req := buildRecorderWithContext("22", "/22?currency=XYZ&date=yesterday")
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp struct {
+		Cause []validation.FieldError `json:"cause"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, []validation.FieldError{
+		{Field: "currency", Rule: validation.ISO4217, Message: "invalid currency"},
+		{Field: "date", Rule: validation.Format, Message: "invalid date, it must be YYYY-MM-DD"},
+	}, resp.Cause)
+}
+
+func TestBoxPriceInvalidQuantity400(t *testing.T) {
+	cases := map[string]validation.FieldError{
+		"six": {Field: "quantity", Rule: validation.Format, Message: "invalid quantity, it must be an integer"},
+		"1.5": {Field: "quantity", Rule: validation.Format, Message: "invalid quantity, it must be an integer"},
+		"0":   {Field: "quantity", Rule: validation.Range, Message: "quantity must be greater than 0"},
+		"-3":  {Field: "quantity", Rule: validation.Range, Message: "quantity must be greater than 0"},
+	}
+	for quantity, expected := range cases {
+		///GIVEN
+		handler := beers.BoxPrice(&ServiceMockOk{})
+		req := buildRecorderWithContext("22", "/22?currency=CLP&quantity=" + quantity)
+		w := httptest.NewRecorder()
+		//WHEN
+		handler(w, req)
+		res := w.Result()
+		var resp struct {
+			Cause []validation.FieldError `json:"cause"`
+		}
+		err := json.NewDecoder(res.Body).Decode(&resp)
+		//THEN
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, quantity)
+		assert.Equal(t, []validation.FieldError{expected}, resp.Cause, quantity)
+	}
+}
+
+func TestBoxPriceInvalidDate400(t *testing.T) {
+	///GIVEN
+	handler := beers.BoxPrice(&ServiceMockOk{})
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	req := buildRecorderWithContext("22", ts.URL + "/22?currency=CLP&date=24-12-2021")
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "invalid date, it must be YYYY-MM-DD", resp["message"])
+}
+
+func TestBoxPriceFutureDate400(t *testing.T) {
+	///GIVEN
+	handler := beers.BoxPrice(&ServiceMockOk{})
+	ts := httptest.NewServer(http.HandlerFunc(handler))
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02")
+	req := buildRecorderWithContext("22", ts.URL + "/22?currency=CLP&date=" + tomorrow)
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "date cannot be in the future", resp["message"])
+}
+
 func TestBoxPriceApiError500(t *testing.T) {
 	///GIVEN
 	handler := beers.BoxPrice(&ServiceMockError{})
@@ -287,7 +703,7 @@ func TestBoxPriceApiError500(t *testing.T) {
 	//THEN
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
-	assert.Equal(t, "error on currencylayer API", resp["message"])
+	assert.Equal(t, "Internal Server Error", resp["message"])
 }
 
 func TestBoxPriceApiError404(t *testing.T) {
@@ -321,20 +737,481 @@ func TestBoxPriceApiOk200(t *testing.T) {
 	//THEN
 	assert.Nil(t, err)
 	assert.Equal(t, http.StatusOK, res.StatusCode)
-	assert.Equal(t, float64(1.2), resp["price"])
+	assert.Equal(t, map[string]interface{}{"amount": float64(1.2), "currency": "USD"}, resp["price"])
 }
 
-func buildRecorderWithContext(beerID string, url string) (*http.Request) {
-	req := httptest.NewRequest("GET", url, nil)
-	rctx := chi.NewRouteContext()
-	rctx.URLParams.Add("beerID", beerID)
-	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
-	return req
+func TestUpdateInvalidBeerID400(t *testing.T) {
+	///GIVEN
+	handler := beers.Update(&ServiceMockOk{})
+	req := buildRequestWithContext(http.MethodPut, "", "/beers/", buildMockBody())
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "invalid beerID", resp["message"])
 }
 
-func buildMockBody() []byte {
-	values := map[string]interface{}{
-		"name": "Golden",
+func TestUpdateEmptyName400(t *testing.T) {
+	///GIVEN
+	handler := beers.Update(&ServiceMockOk{})
+	req := buildRequestWithContext(http.MethodPut, "1", "/beers/1", []byte(`{"price":1.2,"currency":"USD"}`))
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "name cannot be empty", resp["message"])
+}
+
+func TestUpdate404(t *testing.T) {
+	///GIVEN
+	handler := beers.Update(&ServiceMock4XXError{})
+	req := buildRequestWithContext(http.MethodPut, "1", "/beers/1", buildMockBody())
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Equal(t, "beer not found", resp["message"])
+}
+
+func TestUpdateDuplicated409(t *testing.T) {
+	///GIVEN
+	handler := beers.Update(&ServiceMockDuplicated{})
+	req := buildRequestWithContext(http.MethodPut, "1", "/beers/1", buildMockBody())
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+	assert.Equal(t, beers.DuplicatedError.Error(), resp["message"])
+}
+
+func TestUpdateError500(t *testing.T) {
+	///GIVEN
+	handler := beers.Update(&ServiceMockError{})
+	req := buildRequestWithContext(http.MethodPut, "1", "/beers/1", buildMockBody())
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	//THEN
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+}
+
+func TestUpdate200(t *testing.T) {
+	///GIVEN
+	handler := beers.Update(&ServiceMockOk{})
+	req := buildRequestWithContext(http.MethodPut, "1", "/beers/1", buildMockBody())
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "Golden", resp["name"])
+}
+
+func TestPatchEmptyBody400(t *testing.T) {
+	///GIVEN
+	handler := beers.Patch(&ServiceMockOk{})
+	req := buildRequestWithContext(http.MethodPatch, "1", "/beers/1", []byte(`{}`))
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "patch body cannot be empty", resp["message"])
+}
+
+func TestPatchInvalidCurrency400(t *testing.T) {
+	///GIVEN
+	handler := beers.Patch(&ServiceMockOk{})
+	req := buildRequestWithContext(http.MethodPatch, "1", "/beers/1", []byte(`{"currency":"HOLA"}`))
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "currency must be 3 characters long", resp["message"])
+}
+
+func TestPatch404(t *testing.T) {
+	///GIVEN
+	handler := beers.Patch(&ServiceMock4XXError{})
+	req := buildRequestWithContext(http.MethodPatch, "1", "/beers/1", []byte(`{"price":3.5}`))
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	//THEN
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestPatch200(t *testing.T) {
+	///GIVEN
+	handler := beers.Patch(&ServiceMockOk{})
+	req := buildRequestWithContext(http.MethodPatch, "1", "/beers/1", []byte(`{"price":3.5}`))
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, float64(3.5), resp["price"])
+}
+
+func TestDelete204(t *testing.T) {
+	///GIVEN
+	handler := beers.Delete(&ServiceMockOk{}, "")
+	req := buildRequestWithContext(http.MethodDelete, "1", "/beers/1", nil)
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	//THEN
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+}
+
+func TestDelete404(t *testing.T) {
+	///GIVEN
+	handler := beers.Delete(&ServiceMock4XXError{}, "")
+	req := buildRequestWithContext(http.MethodDelete, "1", "/beers/1", nil)
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	assert.Equal(t, "beer not found", resp["message"])
+}
+
+func TestDeletePurgeWithoutAdmin403(t *testing.T) {
+	///GIVEN
+	handler := beers.Delete(&ServiceMockOk{}, "secret")
+	req := buildRequestWithContext(http.MethodDelete, "1", "/beers/1?purge=true", nil)
+	req.Header.Set(auth.AdminTokenHeader, "wrong")
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	//THEN
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+}
+
+func TestDeletePurgeAdmin204(t *testing.T) {
+	///GIVEN
+	handler := beers.Delete(&ServiceMockOk{}, "secret")
+	req := buildRequestWithContext(http.MethodDelete, "1", "/beers/1?purge=true", nil)
+	req.Header.Set(auth.AdminTokenHeader, "secret")
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	//THEN
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+}
+
+func TestRestore200(t *testing.T) {
+	///GIVEN
+	handler := beers.Restore(&ServiceMockOk{})
+	req := buildRequestWithContext(http.MethodPost, "1", "/beers/1/restore", nil)
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, float64(1), resp["id"])
+}
+
+func TestRestore404(t *testing.T) {
+	///GIVEN
+	handler := beers.Restore(&ServiceMock4XXError{})
+	req := buildRequestWithContext(http.MethodPost, "1", "/beers/1/restore", nil)
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	//THEN
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestRestoreDuplicated409(t *testing.T) {
+	///GIVEN
+	handler := beers.Restore(&ServiceMockDuplicated{})
+	req := buildRequestWithContext(http.MethodPost, "1", "/beers/1/restore", nil)
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	//THEN
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+}
+
+func TestImportUnsupportedMediaType415(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Import(&ServiceMockOk{})))
+	defer ts.Close()
+	//WHEN
+	res, _ := http.Post(ts.URL, "application/json", bytes.NewBuffer(buildMockBody()))
+	//THEN
+	assert.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+}
+
+func TestImportMissingCSVColumn400(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Import(&ServiceMockOk{})))
+	defer ts.Close()
+	body := "name,brewery,price\nGolden,Austral,2.5\n"
+	//WHEN
+	res, _ := http.Post(ts.URL, "text/csv", bytes.NewBufferString(body))
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "missing csv column currency", resp["message"])
+}
+
+func TestImportCSV200(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Import(&ServiceMockOk{})))
+	defer ts.Close()
+	body := "\ufeffName,Brewery,Country,Price,Currency\n" +
+		"Golden,Austral,Chile,2.5,USD\n" +
+		"Calafate,Austral,Chile,cheap,CLP\n" +
+		",Austral,Narnia,3,CLP\n"
+	//WHEN
+	res, _ := http.Post(ts.URL+"?dry_run=true", "text/csv; charset=utf-8", bytes.NewBufferString(body))
+	var report beers.ImportReport
+	err := json.NewDecoder(res.Body).Decode(&report)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 2, report.Invalid)
+	assert.Equal(t, beers.ImportRowResult{Line: 3, Status: "invalid", Error: "invalid price", Causes: []validation.FieldError{
+		{Field: "price", Rule: validation.Format, Message: "invalid price"},
+	}}, report.Rows[1])
+	assert.Equal(t, beers.ImportRowResult{Line: 4, Status: "invalid", Error: "name cannot be empty", Causes: []validation.FieldError{
+		{Field: "name", Rule: validation.Required, Message: "name cannot be empty"},
+		{Field: "country", Rule: validation.ISO3166, Message: "country Narnia is not an ISO-3166 country"},
+	}}, report.Rows[2])
+}
+
+func TestImportNDJSON200(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Import(&ServiceMockOk{})))
+	defer ts.Close()
+	body := `{"name":"Golden","price":2.5,"currency":"USD"}` + "\n\n" + `{"name":` + "\n"
+	//WHEN
+	res, _ := http.Post(ts.URL, "application/x-ndjson", bytes.NewBufferString(body))
+	var report beers.ImportReport
+	err := json.NewDecoder(res.Body).Decode(&report)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Invalid)
+	assert.Equal(t, 3, report.Rows[1].Line)
+	assert.Contains(t, report.Rows[1].Error, "invalid json")
+}
+
+func TestImportError500(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Import(&ServiceMockError{})))
+	defer ts.Close()
+	body := `{"name":"Golden","price":2.5,"currency":"USD"}`
+	//WHEN
+	res, _ := http.Post(ts.URL, "application/x-ndjson", bytes.NewBufferString(body))
+	//THEN
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+}
+
+func TestExportCSV200(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Export(&ServiceMockOk{})))
+	defer ts.Close()
+	//WHEN
+	res, _ := http.Get(ts.URL + "?fields=id,brewery,price")
+	body, err := ioutil.ReadAll(res.Body)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/csv; charset=utf-8", res.Header.Get("Content-Type"))
+	assert.Equal(t, "id,brewery,price\n1,\"Kunstmann, Valdivia\",2.5\n2,,1500\n", string(body))
+}
+
+func TestExportDeletedAtOnlyWithTheDeletedBeers200(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Export(&ServiceMockOk{})))
+	defer ts.Close()
+	//WHEN
+	live, _ := http.Get(ts.URL)
+	liveBody, liveErr := ioutil.ReadAll(live.Body)
+	all, _ := http.Get(ts.URL + "?include_deleted=true")
+	allBody, allErr := ioutil.ReadAll(all.Body)
+	//THEN
+	assert.Nil(t, liveErr)
+	assert.Nil(t, allErr)
+	assert.True(t, strings.HasPrefix(string(liveBody), "id,name,brewery,country,price,currency\n"))
+	assert.True(t, strings.HasPrefix(string(allBody), "id,name,brewery,country,price,currency,deleted_at\n"))
+}
+
+func TestExportNDJSONConverted200(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Export(&ServiceMockOk{})))
+	defer ts.Close()
+	//WHEN
+	res, _ := http.Get(ts.URL + "?format=ndjson&target_currency=EUR&fields=name,converted_price,converted_currency")
+	body, err := ioutil.ReadAll(res.Body)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `{"name":"Golden","converted_price":null,"converted_currency":"EUR"}` + "\n" +
+		`{"name":"Calafate","converted_price":20,"converted_currency":"EUR"}` + "\n", string(body))
+}
+
+func TestExportJSONEmpty200(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Export(&ServiceMock4XXError{})))
+	defer ts.Close()
+	//WHEN
+	res, _ := http.Get(ts.URL + "?format=json")
+	body, err := ioutil.ReadAll(res.Body)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "[]", string(body))
+}
+
+func TestExportInvalidParams400(t *testing.T) {
+	cases := map[string]string{
+		"?format=xml":             "invalid format, it must be csv, ndjson or json",
+		"?fields=id,secret":       "invalid field secret",
+		"?fields=converted_price": "invalid field converted_price",
+		"?fields=name,deleted_at": "invalid field deleted_at",
+		"?target_currency=EURO":   "invalid target_currency",
+	}
+	ts := httptest.NewServer(http.HandlerFunc(beers.Export(&ServiceMockOk{})))
+	defer ts.Close()
+	for query, message := range cases {
+		//WHEN
+		res, _ := http.Get(ts.URL + query)
+		var resp map[string]interface{}
+		err := json.NewDecoder(res.Body).Decode(&resp)
+		//THEN
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, query)
+		assert.Equal(t, message, resp["message"], query)
+	}
+}
+
+func TestExportEveryInvalidParam400(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Export(&ServiceMockOk{})))
+	defer ts.Close()
+	//WHEN
+	res, _ := http.Get(ts.URL + "?include_deleted=maybe&sort=deleted_at&target_currency=EURO")
+	var resp struct {
+		Cause []validation.FieldError `json:"cause"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, []validation.FieldError{
+		{Field: "include_deleted", Rule: validation.Format, Message: "invalid include_deleted"},
+		{Field: "sort", Rule: validation.Format, Message: "invalid sort"},
+		{Field: "target_currency", Rule: validation.ISO4217, Message: "invalid target_currency"},
+	}, resp.Cause)
+}
+
+func TestExportPagingParams400(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Export(&ServiceMockOk{})))
+	defer ts.Close()
+	//WHEN
+	res, _ := http.Get(ts.URL + "?limit=10&offset=5")
+	var resp map[string]interface{}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, "limit is not supported, exports stream every matching beer", resp["message"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"field": "limit", "rule": "unsupported", "message": "limit is not supported, exports stream every matching beer"},
+		map[string]interface{}{"field": "offset", "rule": "unsupported", "message": "offset is not supported, exports stream every matching beer"},
+	}, resp["cause"])
+}
+
+func TestExportError500(t *testing.T) {
+	//GIVEN
+	ts := httptest.NewServer(http.HandlerFunc(beers.Export(&ServiceMockError{})))
+	defer ts.Close()
+	//WHEN
+	res, _ := http.Get(ts.URL)
+	//THEN
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+}
+
+func buildRequestWithContext(method string, beerID string, url string, body []byte) *http.Request {
+	req := httptest.NewRequest(method, url, bytes.NewBuffer(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("beerID", beerID)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func buildRecorderWithContext(beerID string, url string) (*http.Request) {
+	req := httptest.NewRequest("GET", url, nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("beerID", beerID)
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+	return req
+}
+
+func buildMockBody() []byte {
+	values := map[string]interface{}{
+		"name": "Golden",
 		"price": 10.3,
 		"currency": "USD",
 	}
@@ -347,54 +1224,212 @@ func buildMockBody() []byte {
 
 type ServiceMockOk struct {}
 
-func (s *ServiceMockOk) List() ([]beers.Beer, error) {
-	return nil, nil
+func (s *ServiceMockOk) List(_ context.Context, params *beers.BeerListParameters) (*beers.BeerPage, error) {
+	return &beers.BeerPage{
+		Results: []beers.Beer{{ID: 1, Name: "test beer"}},
+		Paging:  beers.Paging{Total: 2, Limit: params.Limit, Offset: params.Offset, NextCursor: "next"},
+	}, nil
 }
 
-func (s *ServiceMockOk) Create(b *beers.Beer) (*beers.Beer, error) {
+func (s *ServiceMockOk) Create(_ context.Context, b *beers.Beer) (*beers.Beer, error) {
 	return &beers.Beer{ID: 1, Name: "test beer"}, nil
 }
 
-func (s *ServiceMockOk) Get(id int) (*beers.Beer, error) {
+func (s *ServiceMockOk) Get(_ context.Context, id int) (*beers.Beer, error) {
 	return &beers.Beer{ID: 1}, nil
 }
 
-func (s *ServiceMockOk) BoxPrice(id int, boxParams *beers.BeerBoxParameters) (*beers.BeerBox, error) {
-	return &beers.BeerBox{Price: float64(1.2)}, nil
+func (s *ServiceMockOk) Update(_ context.Context, id int, b *beers.Beer) (*beers.Beer, error) {
+	b.ID = int64(id)
+	return b, nil
+}
+
+func (s *ServiceMockOk) Patch(_ context.Context, id int, p *beers.BeerPatch) (*beers.Beer, error) {
+	b := beers.Beer{ID: int64(id), Name: "test beer", Price: money.RequireFromString("1.2"), Currency: "USD"}
+	if p.Price != nil {
+		b.Price = *p.Price
+	}
+	return &b, nil
+}
+
+func (s *ServiceMockOk) Delete(_ context.Context, id int, purge bool) error {
+	return nil
+}
+
+func (s *ServiceMockOk) Restore(_ context.Context, id int) (*beers.Beer, error) {
+	return &beers.Beer{ID: int64(id), Name: "test beer"}, nil
+}
+
+func (s *ServiceMockOk) Import(_ context.Context, rows []beers.ImportRow, dryRun bool) (*beers.ImportReport, error) {
+	report := beers.ImportReport{DryRun: dryRun}
+	for _, row := range rows {
+		result := beers.ImportRowResult{Line: row.Line, Status: "created", Error: row.Error, Causes: row.Causes}
+		if row.Beer == nil {
+			result.Status = "invalid"
+			report.Invalid++
+		} else {
+			report.Created++
+		}
+		report.Rows = append(report.Rows, result)
+	}
+	return &report, nil
+}
+
+func (s *ServiceMockOk) Export(_ context.Context, params *beers.ExportParameters, fn func(row *beers.ExportRow) error) error {
+	converted := money.NewFromInt(20)
+	rows := []beers.ExportRow{
+		{Beer: beers.Beer{ID: 1, Name: "Golden", Brewery: "Kunstmann, Valdivia", Price: money.RequireFromString("2.5"), Currency: "USD"}},
+		{Beer: beers.Beer{ID: 2, Name: "Calafate", Price: money.NewFromInt(1500), Currency: "CLP"}, ConvertedPrice: &converted},
+	}
+	for i := range rows {
+		if err := fn(&rows[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ServiceMockOk) BoxPrice(_ context.Context, id int, boxParams *beers.BeerBoxParameters) (*beers.BeerBox, error) {
+	return &beers.BeerBox{Price: money.New(money.RequireFromString("1.2"), "USD")}, nil
+}
+
+type createSpy struct {
+	ServiceMockOk
+	onCreate func(b *beers.Beer)
+}
+
+func (s *createSpy) Create(_ context.Context, b *beers.Beer) (*beers.Beer, error) {
+	s.onCreate(b)
+	return b, nil
+}
+
+// rawCursor encodes a cursor payload the way List does.
+func rawCursor(payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(payload))
+}
+
+// deletedListMock answers a soft deleted beer and a live one.
+type deletedListMock struct {
+	ServiceMockOk
+}
+
+func (s *deletedListMock) List(_ context.Context, params *beers.BeerListParameters) (*beers.BeerPage, error) {
+	deletedAt := gorm.DeletedAt{Time: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), Valid: true}
+	return &beers.BeerPage{
+		Results: []beers.Beer{{ID: 1, Name: "deleted beer", DeletedAt: deletedAt}, {ID: 2, Name: "test beer"}},
+		Paging:  beers.Paging{Total: 2, Limit: params.Limit},
+	}, nil
+}
+
+// contextSpy answers the error of the request context, as the service does once it is done.
+type contextSpy struct {
+	ServiceMockOk
+}
+
+func (s *contextSpy) Get(ctx context.Context, id int) (*beers.Beer, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.ServiceMockOk.Get(ctx, id)
 }
 
 type ServiceMockError struct {}
 
-func (s *ServiceMockError) List() ([]beers.Beer, error) {
+func (s *ServiceMockError) List(_ context.Context, params *beers.BeerListParameters) (*beers.BeerPage, error) {
 	return nil, errors.New("database connection lost")
 }
 
-func (s *ServiceMockError) Create(b *beers.Beer) (*beers.Beer, error) {
+func (s *ServiceMockError) Create(_ context.Context, b *beers.Beer) (*beers.Beer, error) {
 	return &beers.Beer{}, errors.New("cannot create new beer")
 }
 
-func (s *ServiceMockError) Get(id int) (*beers.Beer, error) {
+func (s *ServiceMockError) Get(_ context.Context, id int) (*beers.Beer, error) {
 	return nil, errors.New("cannot get beer")
 }
 
-func (s *ServiceMockError) BoxPrice(id int, boxParams *beers.BeerBoxParameters) (*beers.BeerBox, error) {
+func (s *ServiceMockError) Update(_ context.Context, id int, b *beers.Beer) (*beers.Beer, error) {
+	return nil, errors.New("cannot update beer")
+}
+
+func (s *ServiceMockError) Patch(_ context.Context, id int, p *beers.BeerPatch) (*beers.Beer, error) {
+	return nil, errors.New("cannot patch beer")
+}
+
+func (s *ServiceMockError) Delete(_ context.Context, id int, purge bool) error {
+	return errors.New("cannot delete beer")
+}
+
+func (s *ServiceMockError) Restore(_ context.Context, id int) (*beers.Beer, error) {
+	return nil, errors.New("cannot restore beer")
+}
+
+func (s *ServiceMockError) Import(_ context.Context, rows []beers.ImportRow, dryRun bool) (*beers.ImportReport, error) {
+	return nil, errors.New("cannot import beers")
+}
+
+func (s *ServiceMockError) Export(_ context.Context, params *beers.ExportParameters, fn func(row *beers.ExportRow) error) error {
+	return errors.New("cannot export beers")
+}
+
+func (s *ServiceMockError) BoxPrice(_ context.Context, id int, boxParams *beers.BeerBoxParameters) (*beers.BeerBox, error) {
 	return nil, errors.New("error on currencylayer API")
 }
 
 type ServiceMock4XXError struct {}
 
-func (s *ServiceMock4XXError) List() ([]beers.Beer, error) {
+func (s *ServiceMock4XXError) List(_ context.Context, params *beers.BeerListParameters) (*beers.BeerPage, error) {
 	return nil, nil
 }
 
-func (s *ServiceMock4XXError) Create(b *beers.Beer) (*beers.Beer, error) {
+func (s *ServiceMock4XXError) Create(_ context.Context, b *beers.Beer) (*beers.Beer, error) {
 	return &beers.Beer{}, beers.DuplicatedError
 }
 
-func (s *ServiceMock4XXError) Get(id int) (*beers.Beer, error) {
-	return nil, gorm.ErrRecordNotFound
+func (s *ServiceMock4XXError) Get(_ context.Context, id int) (*beers.Beer, error) {
+	return nil, beers.NotFoundError
+}
+
+func (s *ServiceMock4XXError) Update(_ context.Context, id int, b *beers.Beer) (*beers.Beer, error) {
+	return nil, beers.NotFoundError
+}
+
+func (s *ServiceMock4XXError) Patch(_ context.Context, id int, p *beers.BeerPatch) (*beers.Beer, error) {
+	return nil, beers.NotFoundError
+}
+
+func (s *ServiceMock4XXError) Delete(_ context.Context, id int, purge bool) error {
+	return beers.NotFoundError
+}
+
+func (s *ServiceMock4XXError) Restore(_ context.Context, id int) (*beers.Beer, error) {
+	return nil, beers.NotFoundError
+}
+
+func (s *ServiceMock4XXError) Import(_ context.Context, rows []beers.ImportRow, dryRun bool) (*beers.ImportReport, error) {
+	return nil, beers.DuplicatedError
+}
+
+func (s *ServiceMock4XXError) Export(_ context.Context, params *beers.ExportParameters, fn func(row *beers.ExportRow) error) error {
+	return nil
+}
+
+func (s *ServiceMock4XXError) BoxPrice(_ context.Context, id int, boxParams *beers.BeerBoxParameters) (*beers.BeerBox, error) {
+	return nil, beers.NotFoundError
+}
+
+// ServiceMockDuplicated finds every beer but collides on every write.
+type ServiceMockDuplicated struct {
+	ServiceMockOk
+}
+
+func (s *ServiceMockDuplicated) Update(_ context.Context, id int, b *beers.Beer) (*beers.Beer, error) {
+	return nil, beers.DuplicatedError
+}
+
+func (s *ServiceMockDuplicated) Patch(_ context.Context, id int, p *beers.BeerPatch) (*beers.Beer, error) {
+	return nil, beers.DuplicatedError
 }
 
-func (s *ServiceMock4XXError) BoxPrice(id int, boxParams *beers.BeerBoxParameters) (*beers.BeerBox, error) {
-	return nil, gorm.ErrRecordNotFound
+func (s *ServiceMockDuplicated) Restore(_ context.Context, id int) (*beers.Beer, error) {
+	return nil, beers.DuplicatedError
 }
\ No newline at end of file
@@ -0,0 +1,171 @@
+package beers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/validation"
+)
+
+const (
+	csvMediaType    = "text/csv"
+	ndjsonMediaType = "application/x-ndjson"
+	maxImportBytes  = 10 << 20
+	maxImportRows   = 10000
+	importBatchSize = 100
+
+	importStatusCreated    = "created"
+	importStatusDuplicated = "duplicated"
+	importStatusInvalid    = "invalid"
+)
+
+var unsupportedImportError = errors.New("import content type must be " + csvMediaType + " or " + ndjsonMediaType)
+
+// csvColumns are the headers understood on CSV imports, name, price and currency are mandatory.
+var csvColumns = []string{"name", "brewery", "country", "price", "currency"}
+
+func decodeImportBody(w http.ResponseWriter, r *http.Request) ([]ImportRow, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, unsupportedImportError
+	}
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	var rows []ImportRow
+	switch mediaType {
+	case csvMediaType:
+		rows, err = decodeCSVImport(body)
+	case ndjsonMediaType:
+		rows, err = decodeNDJSONImport(body)
+	default:
+		return nil, unsupportedImportError
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("import file has no rows")
+	}
+	return rows, nil
+}
+
+func decodeCSVImport(body io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("import file has no rows")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, h := range header {
+		// Spreadsheets usually prefix their exports with a UTF-8 BOM
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	for _, required := range []string{"name", "price", "currency"} {
+		if _, ok := columns[required]; !ok {
+			return nil, errors.New("missing csv column " + required)
+		}
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+		if len(rows) == maxImportRows {
+			return nil, errors.New("import cannot exceed " + strconv.Itoa(maxImportRows) + " rows")
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, csvImportRow(line, columns, record))
+	}
+	return rows, nil
+}
+
+func csvImportRow(line int, columns map[string]int, record []string) ImportRow {
+	values := make(map[string]string)
+	for _, column := range csvColumns {
+		if i, ok := columns[column]; ok && i < len(record) {
+			values[column] = strings.TrimSpace(record[i])
+		}
+	}
+	var v validation.Errors
+	price, err := money.NewFromString(values["price"])
+	if err != nil {
+		v.Check(validation.NewFieldError("price", validation.Format, "invalid price"))
+	}
+	b := Beer{
+		Name:     values["name"],
+		Brewery:  values["brewery"],
+		Country:  values["country"],
+		Price:    price,
+		Currency: values["currency"],
+	}
+	return validateImportRow(ImportRow{Line: line}, &b, &v)
+}
+
+func decodeNDJSONImport(body io.Reader) ([]ImportRow, error) {
+	scanner := bufio.NewScanner(body)
+	var rows []ImportRow
+	line := 0
+	for scanner.Scan() {
+		line++
+		content := strings.TrimSpace(scanner.Text())
+		if content == "" {
+			continue
+		}
+		if len(rows) == maxImportRows {
+			return nil, errors.New("import cannot exceed " + strconv.Itoa(maxImportRows) + " rows")
+		}
+		row := ImportRow{Line: line}
+		var b Beer
+		if err := json.Unmarshal([]byte(content), &b); err != nil {
+			row.Error = "invalid json: " + err.Error()
+			rows = append(rows, row)
+			continue
+		}
+		rows = append(rows, validateImportRow(row, &b, &validation.Errors{}))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid ndjson: %w", err)
+	}
+	return rows, nil
+}
+
+// validateImportRow adds to v, holding the errors found while parsing the row, the ones of the beer.
+func validateImportRow(row ImportRow, b *Beer, v *validation.Errors) ImportRow {
+	// Imports always create new beers, ids come from the DB
+	b.ID = 0
+	validateBeer(v, b)
+	if err := v.Err(); err != nil {
+		row.Error, row.Causes = err.Error(), v.Causes()
+		return row
+	}
+	row.Beer = b
+	return row
+}
+
+type beerKey struct {
+	name    string
+	brewery string
+	country string
+}
+
+func keyOf(b *Beer) beerKey {
+	return beerKey{name: b.Name, brewery: b.Brewery, country: b.Country}
+}
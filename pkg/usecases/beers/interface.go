@@ -1,8 +1,16 @@
 package beers
 
+import "context"
+
 type Interface interface {
-	List() ([]Beer, error)
-	Create(b *Beer) (*Beer, error)
-	Get(id int) (*Beer, error)
-	BoxPrice(id int, boxParams *BeerBoxParameters) (*BeerBox, error)
+	List(ctx context.Context, params *BeerListParameters) (*BeerPage, error)
+	Create(ctx context.Context, b *Beer) (*Beer, error)
+	Get(ctx context.Context, id int) (*Beer, error)
+	Update(ctx context.Context, id int, b *Beer) (*Beer, error)
+	Patch(ctx context.Context, id int, p *BeerPatch) (*Beer, error)
+	Delete(ctx context.Context, id int, purge bool) error
+	Restore(ctx context.Context, id int) (*Beer, error)
+	Import(ctx context.Context, rows []ImportRow, dryRun bool) (*ImportReport, error)
+	Export(ctx context.Context, params *ExportParameters, fn func(row *ExportRow) error) error
+	BoxPrice(ctx context.Context, id int, boxParams *BeerBoxParameters) (*BeerBox, error)
 }
@@ -0,0 +1,248 @@
+package beers
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryRepository keeps the beers in memory, for the unit tests and the demo mode. Strings are compared byte by
+// byte, unlike the case insensitive collation of MySQL.
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	beers  map[int64]Beer
+	lastID int64
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{beers: make(map[int64]Beer)}
+}
+
+func (r *MemoryRepository) Count(_ context.Context, params *BeerListParameters) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var total int64
+	for id := range r.beers {
+		b := r.beers[id]
+		if matches(&b, params) {
+			total++
+		}
+	}
+	return total, nil
+}
+
+func (r *MemoryRepository) Find(_ context.Context, params *BeerListParameters, limit int) ([]Beer, error) {
+	beers := r.sorted(params)
+	start := params.Offset
+	if params.Cursor != nil {
+		last, err := params.Cursor.beer()
+		if err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(beers), func(i int) bool {
+			return compareBeers(&beers[i], last, params.Sort, params.Order) > 0
+		})
+	}
+	if start > len(beers) {
+		start = len(beers)
+	}
+	beers = beers[start:]
+	if len(beers) > limit {
+		beers = beers[:limit]
+	}
+	return beers, nil
+}
+
+// Each works on a copy of the matching beers, so fn can use the repository too.
+func (r *MemoryRepository) Each(_ context.Context, params *BeerListParameters, fn func(b *Beer) error) error {
+	beers := r.sorted(params)
+	for i := range beers {
+		if err := fn(&beers[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) Get(_ context.Context, id int) (*Beer, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	b, found := r.beers[int64(id)]
+	if !found || b.DeletedAt.Valid {
+		return nil, NotFoundError
+	}
+	return &b, nil
+}
+
+func (r *MemoryRepository) Create(_ context.Context, b *Beer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.taken(b) {
+		return DuplicatedError
+	}
+	if _, found := r.beers[b.ID]; found {
+		return DuplicatedError
+	}
+	r.insert(b, time.Now())
+	return nil
+}
+
+func (r *MemoryRepository) Update(_ context.Context, b *Beer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, found := r.beers[b.ID]
+	if !found || stored.DeletedAt.Valid {
+		return NotFoundError
+	}
+	if r.taken(b) {
+		return DuplicatedError
+	}
+	stored.Name, stored.Brewery, stored.Country = b.Name, b.Brewery, b.Country
+	stored.Price, stored.Currency = b.Price, b.Currency
+	stored.UpdatedAt = time.Now()
+	r.beers[b.ID] = stored
+	b.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+func (r *MemoryRepository) Delete(_ context.Context, id int, purge bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, found := r.beers[int64(id)]
+	if !found || (b.DeletedAt.Valid && !purge) {
+		return NotFoundError
+	}
+	if purge {
+		delete(r.beers, b.ID)
+		return nil
+	}
+	b.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	r.beers[b.ID] = b
+	return nil
+}
+
+func (r *MemoryRepository) Restore(_ context.Context, id int) (*Beer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, found := r.beers[int64(id)]
+	if !found {
+		return nil, NotFoundError
+	}
+	if !b.DeletedAt.Valid {
+		return &b, nil
+	}
+	if r.taken(&b) {
+		return nil, DuplicatedError
+	}
+	b.DeletedAt = gorm.DeletedAt{}
+	r.beers[b.ID] = b
+	return &b, nil
+}
+
+func (r *MemoryRepository) Import(_ context.Context, beers []*Beer, dryRun bool) ([]bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	created := make([]bool, len(beers))
+	seen := make(map[beerKey]bool)
+	now := time.Now()
+	for i, b := range beers {
+		if seen[keyOf(b)] || r.taken(b) {
+			continue
+		}
+		seen[keyOf(b)] = true
+		created[i] = true
+		if !dryRun {
+			r.insert(b, now)
+		}
+	}
+	return created, nil
+}
+
+// insert stores b with the next id, unless it comes with its own.
+func (r *MemoryRepository) insert(b *Beer, now time.Time) {
+	if b.ID == 0 {
+		r.lastID++
+		b.ID = r.lastID
+	} else if b.ID > r.lastID {
+		r.lastID = b.ID
+	}
+	b.CreatedAt, b.UpdatedAt = now, now
+	r.beers[b.ID] = *b
+}
+
+// taken tells if another live beer holds the name, brewery and country of b.
+func (r *MemoryRepository) taken(b *Beer) bool {
+	for id, stored := range r.beers {
+		if id != b.ID && !stored.DeletedAt.Valid && keyOf(&stored) == keyOf(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// sorted copies the beers matching the filters of params, in the order of params.
+func (r *MemoryRepository) sorted(params *BeerListParameters) []Beer {
+	r.mu.RLock()
+	beers := make([]Beer, 0, len(r.beers))
+	for id := range r.beers {
+		b := r.beers[id]
+		if matches(&b, params) {
+			beers = append(beers, b)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(beers, func(i, j int) bool {
+		return compareBeers(&beers[i], &beers[j], params.Sort, params.Order) < 0
+	})
+	return beers
+}
+
+// matches applies the same filters as DBRepository.filterBeers.
+func matches(b *Beer, params *BeerListParameters) bool {
+	switch {
+	case b.DeletedAt.Valid && !params.IncludeDeleted:
+		return false
+	case params.Country != "" && b.Country != params.Country:
+		return false
+	case params.Brewery != "" && b.Brewery != params.Brewery:
+		return false
+	case params.Currency != "" && b.Currency != params.Currency:
+		return false
+	case params.MinPrice != nil && b.Price.Cmp(*params.MinPrice) < 0:
+		return false
+	case params.MaxPrice != nil && b.Price.Cmp(*params.MaxPrice) > 0:
+		return false
+	}
+	return true
+}
+
+// compareBeers compares a and b on the sort column and then on the id, like orderBy does. The result is reversed
+// for the descending order.
+func compareBeers(a *Beer, b *Beer, sort string, order string) int {
+	var c int
+	switch sort {
+	case "name":
+		c = strings.Compare(a.Name, b.Name)
+	case "brewery":
+		c = strings.Compare(a.Brewery, b.Brewery)
+	case "country":
+		c = strings.Compare(a.Country, b.Country)
+	case "price":
+		c = a.Price.Cmp(b.Price)
+	case "currency":
+		c = strings.Compare(a.Currency, b.Currency)
+	}
+	if c == 0 && a.ID != b.ID {
+		c = 1
+		if a.ID < b.ID {
+			c = -1
+		}
+	}
+	if order == orderDesc {
+		return -c
+	}
+	return c
+}
@@ -0,0 +1,167 @@
+package beers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/validation"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+	defaultSort      = "id"
+	orderAsc         = "asc"
+	orderDesc        = "desc"
+)
+
+// sortableColumns maps the sort query param values to their DB column.
+var sortableColumns = map[string]string{
+	"id":       "id",
+	"name":     "name",
+	"brewery":  "brewery",
+	"country":  "country",
+	"price":    "price",
+	"currency": "currency",
+}
+
+// invalidCursorError is a bad request, the cursor was not built by List for the same sort.
+var invalidCursorError = validation.NewFieldError("cursor", validation.Format, "invalid cursor")
+
+// BeerCursor points right after the last beer of a page. It carries the sort it was built for, so it cannot be
+// replayed against a different ordering.
+type BeerCursor struct {
+	Sort  string      `json:"s"`
+	Order string      `json:"o"`
+	Value interface{} `json:"v"`
+	ID    int64       `json:"id"`
+}
+
+func newCursor(b *Beer, sort string, order string) *BeerCursor {
+	c := BeerCursor{Sort: sort, Order: order, ID: b.ID}
+	switch sort {
+	case "name":
+		c.Value = b.Name
+	case "brewery":
+		c.Value = b.Brewery
+	case "country":
+		c.Value = b.Country
+	case "price":
+		// Kept as text, a JSON number would go through float64 when decoded
+		c.Value = b.Price.String()
+	case "currency":
+		c.Value = b.Currency
+	default:
+		c.Value = b.ID
+	}
+	return &c
+}
+
+func (c *BeerCursor) encode() string {
+	bytes, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+func decodeCursor(raw string, sort string, order string) (*BeerCursor, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, invalidCursorError
+	}
+	var c BeerCursor
+	if err = json.Unmarshal(bytes, &c); err != nil {
+		return nil, invalidCursorError
+	}
+	if c.Sort != sort || c.Order != order || c.Value == nil {
+		return nil, invalidCursorError
+	}
+	// The value must have the type of the sort column, as the DB would otherwise compare it with anything
+	if _, err = c.beer(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// beer builds the last beer of the page the cursor points after, holding only its sort column and id.
+func (c *BeerCursor) beer() (*Beer, error) {
+	b := Beer{ID: c.ID}
+	if c.Sort == "id" || c.Sort == "" {
+		// JSON numbers are decoded as float64
+		if _, ok := c.Value.(float64); !ok {
+			return nil, invalidCursorError
+		}
+		return &b, nil
+	}
+	value, ok := c.Value.(string)
+	if !ok {
+		return nil, invalidCursorError
+	}
+	switch c.Sort {
+	case "name":
+		b.Name = value
+	case "brewery":
+		b.Brewery = value
+	case "country":
+		b.Country = value
+	case "price":
+		price, err := money.NewFromString(value)
+		if err != nil {
+			return nil, invalidCursorError
+		}
+		b.Price = price
+	case "currency":
+		b.Currency = value
+	}
+	return &b, nil
+}
+
+// keysetCondition builds the WHERE clause that skips every beer up to the cursor, using the id as tie breaker.
+func (c *BeerCursor) keysetCondition() (string, []interface{}) {
+	column := sortableColumns[c.Sort]
+	op := ">"
+	if c.Order == orderDesc {
+		op = "<"
+	}
+	if column == "id" {
+		return fmt.Sprintf("id %s ?", op), []interface{}{c.ID}
+	}
+	return fmt.Sprintf("((%s %s ?) OR (%s = ? AND id %s ?))", column, op, column, op),
+		[]interface{}{c.Value, c.Value, c.ID}
+}
+
+// linkHeader builds the RFC 8288 Link header pointing to the neighbour pages of the current one.
+func linkHeader(r *http.Request, params *BeerListParameters, page *BeerPage) string {
+	var links []string
+	if page.Paging.NextCursor != "" {
+		links = append(links, pageLink(r, "next", map[string]string{"cursor": page.Paging.NextCursor, "offset": ""}))
+	}
+	if params.Cursor == nil {
+		links = append(links, pageLink(r, "first", map[string]string{"offset": ""}))
+		if params.Offset > 0 {
+			prev := params.Offset - params.Limit
+			if prev < 0 {
+				prev = 0
+			}
+			links = append(links, pageLink(r, "prev", map[string]string{"offset": strconv.Itoa(prev)}))
+		}
+	}
+	return strings.Join(links, ", ")
+}
+
+func pageLink(r *http.Request, rel string, overrides map[string]string) string {
+	q := r.URL.Query()
+	for k, v := range overrides {
+		if v == "" {
+			q.Del(k)
+			continue
+		}
+		q.Set(k, v)
+	}
+	u := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+	return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+}
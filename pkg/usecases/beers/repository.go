@@ -0,0 +1,55 @@
+package beers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// BeerRepository persists the beers. The implementations answer NotFoundError when no beer has the requested id,
+// DuplicatedError when another live beer holds the same name, brewery and country, and ConstraintError when the
+// storage refuses a write for any other reason of its schema.
+type BeerRepository interface {
+	// Count counts the beers matching the filters of params.
+	Count(ctx context.Context, params *BeerListParameters) (int64, error)
+	// Find answers up to limit beers matching the filters of params, ordered by its sort and order. They start
+	// right after its cursor, or at its offset when there is none.
+	Find(ctx context.Context, params *BeerListParameters, limit int) ([]Beer, error)
+	// Each calls fn with every beer matching the filters of params, in the order of params, until fn fails.
+	Each(ctx context.Context, params *BeerListParameters, fn func(b *Beer) error) error
+	Get(ctx context.Context, id int) (*Beer, error)
+	Create(ctx context.Context, b *Beer) error
+	// Update writes the updatable fields of b, zero values included.
+	Update(ctx context.Context, b *Beer) error
+	// Delete soft deletes the beer, or removes it for good when purge is requested.
+	Delete(ctx context.Context, id int, purge bool) error
+	// Restore undeletes a soft deleted beer, unless another beer took its name, brewery and country meanwhile.
+	Restore(ctx context.Context, id int) (*Beer, error)
+	// Import creates at once the beers whose name, brewery and country are free, both on the storage and among the
+	// previous beers of the slice, and sets their ids. created tells which ones, on a dry run nothing is written.
+	Import(ctx context.Context, beers []*Beer, dryRun bool) (created []bool, err error)
+}
+
+// NotFoundError is answered when no beer has the requested id.
+var NotFoundError = errors.New("beer not found")
+
+var DuplicatedError = errors.New("the beer already exist in the DB")
+
+// ConstraintError is a write refused by a constraint of the storage other than the beers uniqueness, like a
+// column that cannot be null.
+type ConstraintError struct {
+	Err error
+}
+
+func (e *ConstraintError) Error() string {
+	return "the beer breaks a constraint of the DB: " + e.Err.Error()
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ConstraintError) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
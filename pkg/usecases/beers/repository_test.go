@@ -0,0 +1,184 @@
+package beers_test
+
+import (
+	"context"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	sqldriver "github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/usecases/beers"
+	"github.com/rgraterol/beers-api/pkg/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+// forEachRepository runs test against the SQLite DB and the in-memory repositories, both starting empty.
+func forEachRepository(t *testing.T, test func(t *testing.T, r beers.BeerRepository)) {
+	t.Run("db", func(t *testing.T) {
+		clearTestDB()
+		test(t, beers.NewDBRepository(testDB))
+	})
+	t.Run("memory", func(t *testing.T) {
+		test(t, beers.NewMemoryRepository())
+	})
+}
+
+func TestRepositoryCreateAndGet(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, r beers.BeerRepository) {
+		// Given
+		b := specificPriceBeerMock()
+		b.ID = 0
+		assert.Nil(t, r.Create(context.Background(), &b))
+		repeated := specificPriceBeerMock()
+		repeated.ID = 0
+		// When
+		found, err := r.Get(context.Background(), int(b.ID))
+		duplicatedErr := r.Create(context.Background(), &repeated)
+		_, notFoundErr := r.Get(context.Background(), int(b.ID)+1)
+		// Then
+		assert.Nil(t, err)
+		assert.Equal(t, "Calafate", found.Name)
+		assert.True(t, money.NewFromInt(1500).Equal(found.Price))
+		assert.True(t, errors.Is(duplicatedErr, beers.DuplicatedError))
+		assert.True(t, errors.Is(notFoundErr, beers.NotFoundError))
+	})
+}
+
+func TestRepositoryCreateSameID(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, r beers.BeerRepository) {
+		// Given
+		b := beerMock()
+		assert.Nil(t, r.Create(context.Background(), &b))
+		other := specificPriceBeerMock()
+		other.ID = b.ID
+		// When
+		err := r.Create(context.Background(), &other)
+		// Then
+		assert.True(t, errors.Is(err, beers.DuplicatedError))
+	})
+}
+
+func TestRepositoryFindWithCursor(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, r beers.BeerRepository) {
+		// Given
+		s := beers.NewService(r, nil, money.HalfUp)
+		createCatalogMock(t, s)
+		params := beers.BeerListParameters{Country: "Chile", Sort: "price", Order: "desc", Limit: 2}
+		page, err := s.List(context.Background(), &params)
+		assert.Nil(t, err)
+		params.Cursor = cursorFrom(t, page.Paging.NextCursor)
+		// When
+		next, err := r.Find(context.Background(), &params, 2)
+		// Then
+		assert.Equal(t, []string{"Bock", "Amber"}, beerNames(page.Results))
+		assert.Equal(t, int64(3), page.Paging.Total)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"Cream"}, beerNames(next))
+	})
+}
+
+func TestMemoryRepositoryFindInvalidCursor(t *testing.T) {
+	// Given
+	r := beers.NewMemoryRepository()
+	params := beers.BeerListParameters{Sort: "name", Order: "asc", Cursor: &beers.BeerCursor{Sort: "name", Order: "asc", Value: 5.0, ID: 1}}
+	// When
+	_, err := r.Find(context.Background(), &params, 2)
+	// Then
+	var fieldErr validation.FieldError
+	assert.True(t, errors.As(err, &fieldErr))
+	assert.Equal(t, "cursor", fieldErr.Field)
+	assert.Equal(t, http.StatusBadRequest, fieldErr.StatusCode())
+}
+
+func TestRepositoryDeleteAndRestore(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, r beers.BeerRepository) {
+		// Given
+		b := beerMock()
+		assert.Nil(t, r.Create(context.Background(), &b))
+		assert.Nil(t, r.Delete(context.Background(), int(b.ID), false))
+		_, err := r.Get(context.Background(), int(b.ID))
+		assert.True(t, errors.Is(err, beers.NotFoundError))
+		// When
+		restored, err := r.Restore(context.Background(), int(b.ID))
+		// Then
+		assert.Nil(t, err)
+		assert.False(t, restored.DeletedAt.Valid)
+		assert.Nil(t, r.Delete(context.Background(), int(b.ID), true))
+		_, err = r.Restore(context.Background(), int(b.ID))
+		assert.True(t, errors.Is(err, beers.NotFoundError))
+		assert.True(t, errors.Is(r.Delete(context.Background(), int(b.ID), true), beers.NotFoundError))
+	})
+}
+
+func TestRepositoryImport(t *testing.T) {
+	forEachRepository(t, func(t *testing.T, r beers.BeerRepository) {
+		// Given
+		existing := beerMock()
+		assert.Nil(t, r.Create(context.Background(), &existing))
+		golden := beerMock()
+		golden.ID = 0
+		calafate := specificPriceBeerMock()
+		calafate.ID = 0
+		repeated := calafate
+		// When
+		created, err := r.Import(context.Background(), []*beers.Beer{&golden, &calafate, &repeated}, false)
+		// Then
+		assert.Nil(t, err)
+		assert.Equal(t, []bool{false, true, false}, created)
+		found, err := r.Get(context.Background(), int(calafate.ID))
+		assert.Nil(t, err)
+		assert.Equal(t, "Calafate", found.Name)
+		total, err := r.Count(context.Background(), &beers.BeerListParameters{})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), total)
+	})
+}
+
+func TestDBRepositoryUniqueLiveKey(t *testing.T) {
+	// Given
+	clearTestDB()
+	deleted := beerMock()
+	deleted.ID = 0
+	assert.Nil(t, testDB.Create(&deleted).Error)
+	assert.Nil(t, testDB.Delete(&deleted).Error)
+	live := beerMock()
+	live.ID = 0
+	assert.Nil(t, testDB.Create(&live).Error)
+	repeated := beerMock()
+	repeated.ID = 0
+	// When
+	err := testDB.Create(&repeated).Error
+	_, restoreErr := beers.NewDBRepository(testDB).Restore(context.Background(), int(deleted.ID))
+	// Then
+	assert.Contains(t, err.Error(), "UNIQUE constraint failed")
+	assert.True(t, errors.Is(restoreErr, beers.DuplicatedError))
+}
+
+func TestDBRepositoryMySQLErrors(t *testing.T) {
+	for number, expected := range map[uint16]func(err error) bool{
+		1062: func(err error) bool { return errors.Is(err, beers.DuplicatedError) },
+		1048: func(err error) bool {
+			var constraintErr *beers.ConstraintError
+			return errors.As(err, &constraintErr)
+		},
+	} {
+		// Given
+		mockDB, mock, _ := sqlmock.New()
+		g, _ := gorm.Open(mysql.New(mysql.Config{Conn: mockDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT count").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec("INSERT INTO `beers`").WillReturnError(&sqldriver.MySQLError{Number: number, Message: "refused"})
+		mock.ExpectRollback()
+		b := beerMock()
+		// When
+		err := beers.NewDBRepository(g).Create(context.Background(), &b)
+		// Then
+		assert.True(t, expected(err), err)
+		assert.Contains(t, err.Error(), "refused")
+		assert.Nil(t, mock.ExpectationsWereMet())
+	}
+}
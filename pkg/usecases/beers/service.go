@@ -1,90 +1,293 @@
 package beers
 
 import (
-	"go.uber.org/zap"
+	"context"
 	"strconv"
-	"strings"
+	"time"
 
 	"github.com/pkg/errors"
-	"github.com/rgraterol/beers-api/pkg/db"
-	"github.com/rgraterol/beers-api/pkg/usecases/currencylayer"
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/tracing"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
 )
 
-type Service struct {}
+// Service runs the beers use cases on a repository and the exchange rates of a provider, rounding the prices
+// with the mode of the currency config.
+type Service struct {
+	repository BeerRepository
+	rates      rates.Interface
+	rounding   money.RoundingMode
+}
+
+func NewService(repository BeerRepository, provider rates.Interface, rounding money.RoundingMode) *Service {
+	return &Service{repository: repository, rates: provider, rounding: rounding}
+}
+
+var invalidTargetCurrencyError = errors.New("invalid target currency")
+
+func (s *Service) List(ctx context.Context, params *BeerListParameters) (*BeerPage, error) {
+	query := withListDefaults(params)
+	page := BeerPage{Paging: Paging{Limit: query.Limit, Offset: query.Offset}}
+	total, err := s.repository.Count(ctx, &query)
+	if err != nil {
+		tracing.Logger(ctx).Error("error counting beers on list", err)
+		return nil, err
+	}
+	page.Paging.Total = total
+	if query.Cursor != nil {
+		page.Paging.Offset = 0
+	}
 
-var DuplicatedError = errors.New("the beer already exist in the DB")
+	// One extra row tells whether there is a next page without a second query.
+	beers, err := s.repository.Find(ctx, &query, query.Limit+1)
+	if err != nil {
+		tracing.Logger(ctx).Error("error on list", err)
+		return nil, err
+	}
+	if len(beers) > query.Limit {
+		beers = beers[:query.Limit]
+		page.Paging.NextCursor = newCursor(&beers[query.Limit-1], query.Sort, query.Order).encode()
+	}
+	page.Results = beers
+	return &page, nil
+}
 
-func (s *Service) List() ([]Beer, error) {
-	var beers []Beer
-	trx := db.Gorm.Find(&beers)
-	if trx.Error != nil {
-		zap.S().Error("error on list", trx.Error)
-		return nil, trx.Error
+// withListDefaults copies params filling the sort, order and limit left empty.
+func withListDefaults(params *BeerListParameters) BeerListParameters {
+	query := *params
+	if query.Sort == "" {
+		query.Sort = defaultSort
+	}
+	if query.Order == "" {
+		query.Order = orderAsc
 	}
-	return beers, nil
+	if query.Limit <= 0 {
+		query.Limit = defaultListLimit
+	}
+	return query
 }
 
-func (s *Service) Create(b *Beer) (*Beer, error) {
-	trx := db.Gorm.Create(b)
-	if trx.Error != nil {
-		if strings.Contains(trx.Error.Error(), "Duplicate") || strings.Contains(trx.Error.Error(), "UNIQUE")  {
-			zap.S().Error(DuplicatedError, trx.Error)
+func (s *Service) Create(ctx context.Context, b *Beer) (*Beer, error) {
+	err := s.repository.Create(ctx, b)
+	if err != nil {
+		if errors.Is(err, DuplicatedError) {
+			tracing.Logger(ctx).Error(DuplicatedError, err)
 			return &Beer{}, DuplicatedError
 		}
-		zap.S().Error("cannot insert beer on DB", trx.Error)
-		return &Beer{}, trx.Error
+		tracing.Logger(ctx).Error("cannot insert beer on DB", err)
+		return &Beer{}, err
+	}
+	return b, nil
+}
+
+func (s *Service) Get(ctx context.Context, id int) (*Beer, error) {
+	b, err := s.repository.Get(ctx, id)
+	if err != nil {
+		tracing.Logger(ctx).Error("error getting beer " + strconv.Itoa(id), err)
+		return nil, err
+	}
+	return b, nil
+}
+
+func (s *Service) Update(ctx context.Context, id int, b *Beer) (*Beer, error) {
+	existing, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	b.ID = existing.ID
+	b.CreatedAt = existing.CreatedAt
+	return s.save(ctx, b)
+}
+
+func (s *Service) Patch(ctx context.Context, id int, p *BeerPatch) (*Beer, error) {
+	existing, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	p.apply(existing)
+	return s.save(ctx, existing)
+}
+
+func (s *Service) save(ctx context.Context, b *Beer) (*Beer, error) {
+	err := s.repository.Update(ctx, b)
+	if err != nil {
+		if errors.Is(err, DuplicatedError) {
+			tracing.Logger(ctx).Error(DuplicatedError, err)
+			return nil, DuplicatedError
+		}
+		tracing.Logger(ctx).Error("cannot update beer " + strconv.FormatInt(b.ID, 10), err)
+		return nil, err
 	}
 	return b, nil
 }
 
-func (s *Service) Get(id int) (*Beer, error) {
-	var b Beer
-	trx := db.Gorm.First(&b, id)
-	if trx.Error != nil {
-		zap.S().Error("error getting beer " + strconv.Itoa(id), trx.Error)
-		return nil, trx.Error
+// Delete soft deletes the beer, or removes its row for good when purge is requested.
+func (s *Service) Delete(ctx context.Context, id int, purge bool) error {
+	err := s.repository.Delete(ctx, id, purge)
+	if err != nil && !errors.Is(err, NotFoundError) {
+		tracing.Logger(ctx).Error("cannot delete beer " + strconv.Itoa(id), err)
 	}
-	return &b, nil
+	return err
 }
 
-func (s *Service) BoxPrice(id int, boxParams *BeerBoxParameters) (*BeerBox, error) {
+// Restore undeletes a soft deleted beer, unless another beer took its name, brewery and country meanwhile.
+func (s *Service) Restore(ctx context.Context, id int) (*Beer, error) {
+	b, err := s.repository.Restore(ctx, id)
+	if err != nil {
+		tracing.Logger(ctx).Error("cannot restore beer " + strconv.Itoa(id), err)
+		return nil, err
+	}
+	return b, nil
+}
+
+// Import validates every row against the repository and the rest of the file, then creates the valid ones all
+// at once. On a dry run nothing is written.
+func (s *Service) Import(ctx context.Context, rows []ImportRow, dryRun bool) (*ImportReport, error) {
+	report := ImportReport{DryRun: dryRun, Rows: make([]ImportRowResult, len(rows))}
+	var valid []*Beer
+	var validRows []int
+	for i, row := range rows {
+		result := &report.Rows[i]
+		result.Line = row.Line
+		if row.Beer == nil {
+			result.Status, result.Error, result.Causes = importStatusInvalid, row.Error, row.Causes
+			report.Invalid++
+			continue
+		}
+		valid = append(valid, row.Beer)
+		validRows = append(validRows, i)
+	}
+	created, err := s.repository.Import(ctx, valid, dryRun)
+	if err != nil {
+		if errors.Is(err, DuplicatedError) {
+			tracing.Logger(ctx).Error(DuplicatedError, err)
+			return nil, DuplicatedError
+		}
+		tracing.Logger(ctx).Error("cannot import beers", err)
+		return nil, err
+	}
+	for i, b := range valid {
+		result := &report.Rows[validRows[i]]
+		if created[i] {
+			result.Status, result.ID = importStatusCreated, b.ID
+			report.Created++
+		} else {
+			result.Status, result.Error = importStatusDuplicated, DuplicatedError.Error()
+			report.Duplicated++
+		}
+	}
+	return &report, nil
+}
+
+// Export streams the beers matching params to fn one at a time, as the repository finds them.
+func (s *Service) Export(ctx context.Context, params *ExportParameters, fn func(row *ExportRow) error) error {
+	var exchangeRates *rates.Rates
+	if params.TargetCurrency != "" {
+		var err error
+		exchangeRates, err = s.rates.GetRates(ctx)
+		if err != nil {
+			return errors.Wrap(err, "cannot access exchange rates provider")
+		}
+		if exchangeRates.Rate(params.TargetCurrency).IsZero() {
+			return invalidTargetCurrencyError
+		}
+	}
+
+	// Only the repository errors are logged here, the caller knows what went wrong on fn
+	var fnErr error
+	query := withListDefaults(&params.List)
+	err := s.repository.Each(ctx, &query, func(b *Beer) error {
+		row := ExportRow{Beer: *b}
+		if exchangeRates != nil {
+			row.ConvertedPrice = exportConvertedPrice(exchangeRates, b, params.TargetCurrency, s.rounding)
+		}
+		fnErr = fn(&row)
+		return fnErr
+	})
+	if err != nil && err != fnErr {
+		tracing.Logger(ctx).Error("cannot export beers", err)
+	}
+	return err
+}
+
+// exportConvertedPrice converts a single beer price with the same cross rates as calculateConvertedPrice,
+// it is nil when the beer currency is unknown.
+func exportConvertedPrice(exchangeRates *rates.Rates, b *Beer, target string, mode money.RoundingMode) *money.Decimal {
+	if b.Currency == target {
+		price := b.Price
+		return &price
+	}
+	rate, ok := conversionRate(exchangeRates, b.Currency, target)
+	if !ok {
+		return nil
+	}
+	price := money.New(b.Price, b.Currency).Convert(rate, target, mode).Amount
+	return &price
+}
+
+func (s *Service) BoxPrice(ctx context.Context, id int, boxParams *BeerBoxParameters) (*BeerBox, error) {
 	var box BeerBox
 	box.Target = *boxParams
-	b, err := s.Get(id)
+	b, err := s.Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	box.Beer = *b
-	box.Price, err = calculateConvertedPrice(boxParams, b)
+	box.Price, box.Rate, err = s.calculateConvertedPrice(ctx, boxParams, b)
 	if err != nil {
-		zap.S().Error(err)
+		tracing.Logger(ctx).Error(err)
 		return nil, err
 	}
 	return &box, nil
 }
 
-func calculateConvertedPrice(boxParams *BeerBoxParameters, b *Beer) (float64, error) {
+func (s *Service) calculateConvertedPrice(ctx context.Context, boxParams *BeerBoxParameters, b *Beer) (money.Money, *BeerBoxRate, error) {
+	box := money.New(b.Price.Mul(money.NewFromInt(boxParams.Quantity)), b.Currency)
 	// If two correncies are the same, or doesnt request for a currency conversion
 	if boxParams.Currency == "" || boxParams.Currency == b.Currency {
-		return float64(boxParams.Quantity) * b.Price, nil
+		return box.RoundWith(s.rounding), nil, nil
+	}
+	exchangeRates, err := s.getExchangeRates(ctx, boxParams.Date)
+	if err != nil {
+		return money.Money{}, nil, errors.Wrap(err, "cannot access exchange rates provider")
 	}
-	currencyLayer, err := currencylayer.Layer.GetCurrency()
+	// We check the provider knows both the requested currency and the beer storage currency
+	if exchangeRates.Rate(boxParams.Currency).IsZero() {
+		return money.Money{}, nil, invalidTargetCurrencyError
+	}
+	conversion, ok := conversionRate(exchangeRates, b.Currency, boxParams.Currency)
+	if !ok {
+		return money.Money{}, nil, errors.New("invalid beer currency")
+	}
+	rate := BeerBoxRate{
+		Source:    exchangeRates.Source,
+		Timestamp: exchangeRates.Timestamp,
+		Value:     conversion,
+		Date:      boxParams.Date,
+	}
+	// Finally we multiply the box price by the conversion rate, rounding it to the target minor units
+	return box.Convert(conversion, boxParams.Currency, s.rounding), &rate, nil
+}
+
+// conversionRate is the amount of target units bought by one unit of from, going through the provider base
+// currency. It is not ok when the provider doesn't know any of them.
+func conversionRate(exchangeRates *rates.Rates, from string, target string) (money.Decimal, bool) {
+	baseFrom := exchangeRates.Rate(from)
+	baseTarget := exchangeRates.Rate(target)
+	if baseFrom.IsZero() || baseTarget.IsZero() {
+		return money.Zero, false
+	}
+	return baseTarget.Div(baseFrom), true
+}
+
+// getExchangeRates asks for the current rates, or the historical ones when a date is given.
+func (s *Service) getExchangeRates(ctx context.Context, date string) (*rates.Rates, error) {
+	if date == "" {
+		return s.rates.GetRates(ctx)
+	}
+	day, err := time.Parse(rates.DateLayout, date)
 	if err != nil {
-		return 0, errors.Wrap(err, "cannot access currencyLayer API")
-	}
-	// We get the conversion rate to USD for the requested currency
-	usdTarget := currencyLayer.Quotes[currencylayer.DefaultCurrency+ boxParams.Currency]
-	if usdTarget == 0 {
-		return 0, errors.New("invalid target currency")
-	}
-	// We get the conversion rate to USD for the beer storage currency
-	usdBeer := currencyLayer.Quotes[currencylayer.DefaultCurrency+ b.Currency]
-	if usdTarget == 0 {
-		return 0, errors.New("invalid beer currency")
-	}
-	// We get the conversion rate from the beer storage currency to the target one
-	conversionRate := usdTarget / usdBeer
-	// Finally we multiply the conversion rate with the beer price to get the price in the new currency
-	// and we multiply it by the amount of beers in the box
-	return b.Price * conversionRate * float64(boxParams.Quantity), nil
+		return nil, errors.Wrap(err, "invalid date")
+	}
+	return rates.HistoricalRates(ctx, s.rates, day)
 }
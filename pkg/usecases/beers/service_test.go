@@ -1,20 +1,25 @@
 package beers_test
 
 import (
+	"context"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/pkg/errors"
-	"github.com/rgraterol/beers-api/cmd/api/initializers"
-	"github.com/rgraterol/beers-api/pkg/db"
+	"github.com/rgraterol/beers-api/pkg/money"
 	"github.com/rgraterol/beers-api/pkg/usecases/beers"
-	"github.com/rgraterol/beers-api/pkg/usecases/currencylayer"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
 	"github.com/stretchr/testify/assert"
 )
 
+var testDB *gorm.DB
+
 func init() {
 	initTestDB()
 }
@@ -22,10 +27,10 @@ func init() {
 func TestCreateOk(t *testing.T) {
 	// Given
 	clearTestDB()
-	var s beers.Service
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
 	b := beerMock()
 	// When
-	_, err := s.Create(&b)
+	_, err := s.Create(context.Background(), &b)
 	// Then
 	assert.Nil(t, err)
 }
@@ -33,12 +38,12 @@ func TestCreateOk(t *testing.T) {
 func TestCreateDuplicated(t *testing.T) {
 	// Given
 	clearTestDB()
-	var s beers.Service
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
 	b := duplicatedbeerMock()
 	// When
-	_, err := s.Create(&b)
+	_, err := s.Create(context.Background(), &b)
 	assert.Nil(t, err)
-	_, err = s.Create(&b)
+	_, err = s.Create(context.Background(), &b)
 	// Then
 	assert.NotNil(t, err)
 	assert.Equal(t, beers.DuplicatedError, err)
@@ -46,12 +51,10 @@ func TestCreateDuplicated(t *testing.T) {
 
 func TestCreateError(t *testing.T) {
 	// Given
-	mockBrokenDB()
-	defer initializers.MockDatabaseInitializer()
-	var s beers.Service
+	s := beers.NewService(beers.NewDBRepository(mockBrokenDB()), nil, money.HalfUp)
 	b := beerMock()
 	// When
-	_, err := s.Create(&b)
+	_, err := s.Create(context.Background(), &b)
 	// Then
 	assert.NotNil(t, err)
 	assert.NotEqual(t, beers.DuplicatedError, err)
@@ -59,47 +62,199 @@ func TestCreateError(t *testing.T) {
 
 func TestListError(t *testing.T) {
 	// Given
-	mockBrokenDB()
-	defer initializers.MockDatabaseInitializer()
-	var s beers.Service
+	s := beers.NewService(beers.NewDBRepository(mockBrokenDB()), nil, money.HalfUp)
 	// When
-	bs, err := s.List()
+	page, err := s.List(context.Background(), &beers.BeerListParameters{})
 	// Then
 	assert.NotNil(t, err)
-	assert.Equal(t, 0, len(bs))
+	assert.Nil(t, page)
 }
 
 func TestListEmpty(t *testing.T) {
 	// Given
 	clearTestDB()
-	var s beers.Service
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
 	// When
-	bs, err := s.List()
+	page, err := s.List(context.Background(), &beers.BeerListParameters{})
 	// Then
 	assert.Nil(t, err)
-	assert.Equal(t, 0, len(bs))
+	assert.Equal(t, 0, len(page.Results))
+	assert.Equal(t, int64(0), page.Paging.Total)
 }
 
 func TestListWithItems(t *testing.T) {
 	// Given
 	clearTestDB()
-	var s beers.Service
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
 	b := beerMock()
-	beerCreate, err := s.Create(&b)
+	beerCreate, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	// When
+	page, err := s.List(context.Background(), &beers.BeerListParameters{})
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, page.Results[0].ID, beerCreate.ID)
+}
+
+func TestListFilters(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	createCatalogMock(t, s)
+	minPrice, maxPrice := money.NewFromInt(2), money.NewFromInt(10)
+	// When
+	page, err := s.List(context.Background(), &beers.BeerListParameters{
+		Country:  "Chile",
+		Currency: "USD",
+		MinPrice: &minPrice,
+		MaxPrice: &maxPrice,
+	})
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), page.Paging.Total)
+	assert.Equal(t, "Amber", page.Results[0].Name)
+	assert.Equal(t, "Bock", page.Results[1].Name)
+}
+
+func TestListSortedWithCursor(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	createCatalogMock(t, s)
+	params := beers.BeerListParameters{Sort: "price", Order: "desc", Limit: 2}
+	// When
+	first, err := s.List(context.Background(), &params)
+	assert.Nil(t, err)
+	params.Cursor = cursorFrom(t, first.Paging.NextCursor)
+	second, err := s.List(context.Background(), &params)
+	// Then
 	assert.Nil(t, err)
+	assert.Equal(t, int64(4), first.Paging.Total)
+	assert.Equal(t, []string{"Dunkel", "Bock"}, beerNames(first.Results))
+	assert.Equal(t, []string{"Amber", "Cream"}, beerNames(second.Results))
+	assert.Equal(t, "", second.Paging.NextCursor)
+}
+
+func TestListOffset(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	createCatalogMock(t, s)
 	// When
-	bs, err := s.List()
+	page, err := s.List(context.Background(), &beers.BeerListParameters{Sort: "name", Limit: 2, Offset: 3})
 	// Then
 	assert.Nil(t, err)
-	assert.Equal(t, bs[0].ID, beerCreate.ID)
+	assert.Equal(t, []string{"Dunkel"}, beerNames(page.Results))
+	assert.Equal(t, "", page.Paging.NextCursor)
+}
+
+func TestImportReport(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	b := beerMock()
+	_, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	rows := importRowsMock()
+	// When
+	report, err := s.Import(context.Background(), rows, false)
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 2, report.Duplicated)
+	assert.Equal(t, 1, report.Invalid)
+	assert.Equal(t, []string{"duplicated", "created", "duplicated", "invalid"}, importStatuses(report))
+	fetchedB, err := s.Get(context.Background(), int(report.Rows[1].ID))
+	assert.Nil(t, err)
+	assert.Equal(t, "Calafate", fetchedB.Name)
+}
+
+func TestImportDryRun(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	// When
+	report, err := s.Import(context.Background(), importRowsMock(), true)
+	// Then
+	assert.Nil(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 1, report.Duplicated)
+	page, err := s.List(context.Background(), &beers.BeerListParameters{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(page.Results))
+}
+
+func TestImportError(t *testing.T) {
+	// Given
+	s := beers.NewService(beers.NewDBRepository(mockBrokenDB()), nil, money.HalfUp)
+	// When
+	report, err := s.Import(context.Background(), importRowsMock(), false)
+	// Then
+	assert.NotNil(t, err)
+	assert.Nil(t, report)
+}
+
+func TestExportStreamsConvertedRows(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), &mockLayerOk{}, money.HalfUp)
+	b := specificPriceBeerMock()
+	_, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	unknown := beerMock()
+	_, err = s.Create(context.Background(), &unknown)
+	assert.Nil(t, err)
+	var rows []beers.ExportRow
+	// When
+	err = s.Export(context.Background(), &beers.ExportParameters{TargetCurrency: "ARS"}, func(row *beers.ExportRow) error {
+		rows = append(rows, *row)
+		return nil
+	})
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(rows))
+	assert.Equal(t, "GoldenMock", rows[0].Beer.Name)
+	assert.Nil(t, rows[0].ConvertedPrice)
+	assert.Equal(t, "Calafate", rows[1].Beer.Name)
+	assert.Equal(t, "190.75", rows[1].ConvertedPrice.String())
+}
+
+func TestExportInvalidTargetCurrency(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), &mockLayerOk{}, money.HalfUp)
+	called := false
+	// When
+	err := s.Export(context.Background(), &beers.ExportParameters{TargetCurrency: "NYC"}, func(row *beers.ExportRow) error {
+		called = true
+		return nil
+	})
+	// Then
+	assert.NotNil(t, err)
+	assert.Equal(t, "invalid target currency", err.Error())
+	assert.False(t, called)
+}
+
+func TestListCancelled(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// When
+	page, err := s.List(ctx, &beers.BeerListParameters{})
+	// Then
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Nil(t, page)
 }
 
 func TestGetNotFound(t *testing.T) {
 	// Given
 	clearTestDB()
-	var s beers.Service
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
 	// When
-	b, err := s.Get(1)
+	b, err := s.Get(context.Background(), 1)
 	// Then
 	assert.NotNil(t, err)
 	assert.Nil(t,b)
@@ -108,23 +263,203 @@ func TestGetNotFound(t *testing.T) {
 func TestGetOK(t *testing.T) {
 	// Given
 	clearTestDB()
-	var s beers.Service
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
 	b := beerMock()
 	// When
-	_, err := s.Create(&b)
-	fetchedB, err := s.Get(1)
+	_, err := s.Create(context.Background(), &b)
+	fetchedB, err := s.Get(context.Background(), 1)
 	// Then
 	assert.Nil(t, err)
 	assert.NotNil(t,fetchedB)
 	assert.Equal(t, b.ID, fetchedB.ID)
 }
 
+func TestUpdateNotFound(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	b := beerMock()
+	// When
+	updatedB, err := s.Update(context.Background(), 1, &b)
+	// Then
+	assert.True(t, errors.Is(err, beers.NotFoundError))
+	assert.Nil(t, updatedB)
+}
+
+func TestUpdateOK(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	b := beerMock()
+	_, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	update := specificPriceBeerMock()
+	// When
+	updatedB, err := s.Update(context.Background(), 1, &update)
+	// Then
+	assert.Nil(t, err)
+	fetchedB, err := s.Get(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), updatedB.ID)
+	assert.Equal(t, "Calafate", fetchedB.Name)
+	assert.Equal(t, "1500", fetchedB.Price.String())
+}
+
+func TestUpdateDuplicated(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	b := beerMock()
+	_, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	other := specificPriceBeerMock()
+	_, err = s.Create(context.Background(), &other)
+	assert.Nil(t, err)
+	update := beerMock()
+	// When
+	_, err = s.Update(context.Background(), 2, &update)
+	// Then
+	assert.Equal(t, beers.DuplicatedError, err)
+}
+
+func TestPatchOK(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	b := beerMock()
+	_, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	price := money.NewFromInt(4)
+	// When
+	patchedB, err := s.Patch(context.Background(), 1, &beers.BeerPatch{Price: &price})
+	// Then
+	assert.Nil(t, err)
+	fetchedB, err := s.Get(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "4", patchedB.Price.String())
+	assert.Equal(t, "4", fetchedB.Price.String())
+	assert.Equal(t, b.Name, fetchedB.Name)
+}
+
+func TestDeleteNotFound(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	// When
+	err := s.Delete(context.Background(), 1, false)
+	// Then
+	assert.True(t, errors.Is(err, beers.NotFoundError))
+}
+
+func TestDeleteSoft(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	b := beerMock()
+	_, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	// When
+	err = s.Delete(context.Background(), 1, false)
+	// Then
+	assert.Nil(t, err)
+	_, err = s.Get(context.Background(), 1)
+	assert.True(t, errors.Is(err, beers.NotFoundError))
+	page, err := s.List(context.Background(), &beers.BeerListParameters{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(page.Results))
+	page, err = s.List(context.Background(), &beers.BeerListParameters{IncludeDeleted: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(page.Results))
+	assert.True(t, page.Results[0].DeletedAt.Valid)
+}
+
+func TestDeletePurge(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	b := beerMock()
+	_, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	assert.Nil(t, s.Delete(context.Background(), 1, false))
+	// When
+	err = s.Delete(context.Background(), 1, true)
+	// Then
+	assert.Nil(t, err)
+	page, err := s.List(context.Background(), &beers.BeerListParameters{IncludeDeleted: true})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(page.Results))
+}
+
+func TestCreateReusesDeletedSlot(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	b := beerMock()
+	_, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	assert.Nil(t, s.Delete(context.Background(), 1, false))
+	reused := beerMock()
+	reused.ID = 3
+	// When
+	_, err = s.Create(context.Background(), &reused)
+	// Then
+	assert.Nil(t, err)
+}
+
+func TestRestoreOK(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	b := beerMock()
+	_, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	assert.Nil(t, s.Delete(context.Background(), 1, false))
+	// When
+	restoredB, err := s.Restore(context.Background(), 1)
+	// Then
+	assert.Nil(t, err)
+	assert.False(t, restoredB.DeletedAt.Valid)
+	fetchedB, err := s.Get(context.Background(), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, b.Name, fetchedB.Name)
+}
+
+func TestRestoreNotFound(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	// When
+	b, err := s.Restore(context.Background(), 1)
+	// Then
+	assert.True(t, errors.Is(err, beers.NotFoundError))
+	assert.Nil(t, b)
+}
+
+func TestRestoreSlotReused(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	b := beerMock()
+	_, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	assert.Nil(t, s.Delete(context.Background(), 1, false))
+	reused := beerMock()
+	reused.ID = 3
+	_, err = s.Create(context.Background(), &reused)
+	assert.Nil(t, err)
+	// When
+	restoredB, err := s.Restore(context.Background(), 1)
+	// Then
+	assert.Equal(t, beers.DuplicatedError, err)
+	assert.Nil(t, restoredB)
+}
+
 func TestBoxPriceNotFoundError(t *testing.T) {
 	// Given
 	clearTestDB()
-	var s beers.Service
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
 	// When
-	b, err := s.BoxPrice(1, &beers.BeerBoxParameters{})
+	b, err := s.BoxPrice(context.Background(), 1, &beers.BeerBoxParameters{})
 	// Then
 	assert.NotNil(t, err)
 	assert.Nil(t,b)
@@ -133,31 +468,29 @@ func TestBoxPriceNotFoundError(t *testing.T) {
 func TestBoxPriceClientLayerError(t *testing.T) {
 	// Given
 	clearTestDB()
-	var s beers.Service
+	s := beers.NewService(beers.NewDBRepository(testDB), &mockLayerError{}, money.HalfUp)
 	b := specificPriceBeerMock()
-	_, err := s.Create(&b)
+	_, err := s.Create(context.Background(), &b)
 	assert.Nil(t, err)
-	currencylayer.Layer = &mockLayerError{}
 	// When
-	p, err := s.BoxPrice(2, &beers.BeerBoxParameters{
+	p, err := s.BoxPrice(context.Background(), 2, &beers.BeerBoxParameters{
 		Currency: "NYC",
 	})
 	// Then
 	assert.NotNil(t, err)
 	assert.Nil(t,p)
-	assert.Contains(t, err.Error(), "cannot access currencyLayer API")
+	assert.Contains(t, err.Error(), "cannot access exchange rates provider")
 }
 
 func TestBoxPriceInvalidCurrencyError(t *testing.T) {
 	// Given
 	clearTestDB()
-	var s beers.Service
+	s := beers.NewService(beers.NewDBRepository(testDB), &mockLayerOk{}, money.HalfUp)
 	b := specificPriceBeerMock()
-	_, err := s.Create(&b)
+	_, err := s.Create(context.Background(), &b)
 	assert.Nil(t, err)
-	currencylayer.Layer = &mockLayerOk{}
 	// When
-	p, err := s.BoxPrice(2, &beers.BeerBoxParameters{
+	p, err := s.BoxPrice(context.Background(), 2, &beers.BeerBoxParameters{
 		Currency: "NYC",
 	})
 	// Then
@@ -166,27 +499,207 @@ func TestBoxPriceInvalidCurrencyError(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid target currency")
 }
 
+func TestBoxPriceInvalidBeerCurrencyError(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), &mockLayerOk{}, money.HalfUp)
+	b := specificPriceBeerMock()
+	b.Currency = "XXX"
+	_, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	// When
+	p, err := s.BoxPrice(context.Background(), 2, &beers.BeerBoxParameters{
+		Quantity: 6,
+		Currency: "ARS",
+	})
+	// Then
+	assert.NotNil(t, err)
+	assert.Nil(t, p)
+	assert.Contains(t, err.Error(), "invalid beer currency")
+}
+
+func TestBoxPriceRoundsWithServiceMode(t *testing.T) {
+	// Given
+	repository := beers.NewMemoryRepository()
+	b := beerMock()
+	b.Price = money.RequireFromString("10.125")
+	b.Currency = "USD"
+	assert.Nil(t, repository.Create(context.Background(), &b))
+	// When
+	halfUp, err := beers.NewService(repository, nil, money.HalfUp).BoxPrice(context.Background(), int(b.ID), &beers.BeerBoxParameters{Quantity: 1})
+	assert.Nil(t, err)
+	halfEven, err := beers.NewService(repository, nil, money.HalfEven).BoxPrice(context.Background(), int(b.ID), &beers.BeerBoxParameters{Quantity: 1})
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "10.13", halfUp.Price.Amount.String())
+	assert.Equal(t, "10.12", halfEven.Price.Amount.String())
+}
+
 func TestBoxPriceOkConversion(t *testing.T) {
 	// Given
 	clearTestDB()
-	var s beers.Service
+	s := beers.NewService(beers.NewDBRepository(testDB), &mockLayerOk{}, money.HalfUp)
 	b := specificPriceBeerMock()
-	_, err := s.Create(&b)
+	_, err := s.Create(context.Background(), &b)
 	assert.Nil(t, err)
-	currencylayer.Layer = &mockLayerOk{}
 	// When
-	p, err := s.BoxPrice(2, &beers.BeerBoxParameters{
+	p, err := s.BoxPrice(context.Background(), 2, &beers.BeerBoxParameters{
 		Quantity: 12,
 		Currency: "ARS",
 	})
 	// Then
 	assert.Nil(t, err)
 	assert.NotNil(t, p)
-	assert.Equal(t, float64(2288.9676977167974), p.Price)
+	assert.Equal(t, "2288.97", p.Price.Amount.String())
+	assert.Equal(t, "ARS", p.Price.Currency)
 	assert.Equal(t, b.ID, p.Beer.ID)
 	assert.Equal(t, "ARS", p.Target.Currency)
+	assert.Equal(t, "mock", p.Rate.Source)
+	assert.Equal(t, time.Date(2022, 2, 6, 8, 11, 5, 0, time.UTC), p.Rate.Timestamp)
+	assert.Equal(t, "0.1271648720953776", p.Rate.Value.String())
+}
+
+func TestBoxPriceKeepsEveryDigitOfTheRates(t *testing.T) {
+	// Given
+	clearTestDB()
+	provider := rates.ProviderFunc(func(context.Context) (*rates.Rates, error) {
+		return &rates.Rates{Base: "USD", Source: "mock", Rates: map[string]money.Decimal{
+			"CLP": money.RequireFromString("828.5039120000000001"),
+		}}, nil
+	})
+	s := beers.NewService(beers.NewDBRepository(testDB), provider, money.HalfUp)
+	b := beers.Beer{ID: 3, Name: "Golden", Brewery: "Kunstmann", Price: money.NewFromInt(10), Currency: "USD"}
+	_, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	// When
+	p, err := s.BoxPrice(context.Background(), 3, &beers.BeerBoxParameters{Quantity: 6, Currency: "CLP"})
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "828.5039120000000001", p.Rate.Value.String())
+	assert.Equal(t, "49710", p.Price.Amount.String())
+}
+
+func TestBoxPriceKeepsCents(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), nil, money.HalfUp)
+	b := beerMock()
+	b.Price = money.RequireFromString("19.99")
+	_, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	// When
+	p, err := s.BoxPrice(context.Background(), int(b.ID), &beers.BeerBoxParameters{Quantity: 3})
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "59.97", p.Price.Amount.String())
+	assert.Equal(t, b.Currency, p.Price.Currency)
+	assert.Nil(t, p.Rate)
+}
+
+func TestBoxPriceHistoricalConversion(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), &mockLayerOk{}, money.HalfUp)
+	b := specificPriceBeerMock()
+	_, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	// When
+	p, err := s.BoxPrice(context.Background(), 2, &beers.BeerBoxParameters{
+		Quantity: 6,
+		Currency: "USD",
+		Date:     "2021-12-24",
+	})
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "12", p.Price.Amount.String())
+	assert.Equal(t, "2021-12-24", p.Target.Date)
+	assert.Equal(t, "2021-12-24", p.Rate.Date)
+	assert.Equal(t, time.Date(2021, 12, 24, 23, 59, 59, 0, time.UTC), p.Rate.Timestamp)
+	assert.Equal(t, "0.0013333333333333", p.Rate.Value.String())
 }
 
+func TestBoxPriceHistoricalUnsupportedError(t *testing.T) {
+	// Given
+	clearTestDB()
+	s := beers.NewService(beers.NewDBRepository(testDB), &mockLayerError{}, money.HalfUp)
+	b := specificPriceBeerMock()
+	_, err := s.Create(context.Background(), &b)
+	assert.Nil(t, err)
+	// When
+	p, err := s.BoxPrice(context.Background(), 2, &beers.BeerBoxParameters{
+		Quantity: 6,
+		Currency: "USD",
+		Date:     "2021-12-24",
+	})
+	// Then
+	assert.Nil(t, p)
+	assert.True(t, errors.Is(err, rates.HistoricalUnsupportedError))
+}
+
+
+func createCatalogMock(t *testing.T, s *beers.Service) {
+	catalog := []beers.Beer{
+		{Name: "Amber", Brewery: "Austral", Country: "Chile", Price: money.NewFromInt(3), Currency: "USD"},
+		{Name: "Bock", Brewery: "Kunstmann", Country: "Chile", Price: money.NewFromInt(5), Currency: "USD"},
+		{Name: "Cream", Brewery: "Kunstmann", Country: "Chile", Price: money.NewFromInt(1), Currency: "USD"},
+		{Name: "Dunkel", Brewery: "Paulaner", Country: "Germany", Price: money.NewFromInt(7), Currency: "EUR"},
+	}
+	for i := range catalog {
+		_, err := s.Create(context.Background(), &catalog[i])
+		assert.Nil(t, err)
+	}
+}
+
+// cursorFrom decodes a next_cursor the same way the List handler does.
+func cursorFrom(t *testing.T, raw string) *beers.BeerCursor {
+	req := httptest.NewRequest(http.MethodGet, "/beers?sort=price&order=desc&cursor="+raw, nil)
+	var cursor *beers.BeerCursor
+	handler := beers.List(&listParamsSpy{onList: func(params *beers.BeerListParameters) {
+		cursor = params.Cursor
+	}})
+	handler(httptest.NewRecorder(), req)
+	assert.NotNil(t, cursor)
+	return cursor
+}
+
+// importRowsMock holds a beerMock, a new beer, the same new beer again and an invalid row.
+func importRowsMock() []beers.ImportRow {
+	golden := beerMock()
+	calafate := specificPriceBeerMock()
+	repeated := specificPriceBeerMock()
+	return []beers.ImportRow{
+		{Line: 2, Beer: &golden},
+		{Line: 3, Beer: &calafate},
+		{Line: 4, Beer: &repeated},
+		{Line: 5, Error: "name cannot be empty"},
+	}
+}
+
+func importStatuses(report *beers.ImportReport) []string {
+	statuses := make([]string, 0, len(report.Rows))
+	for _, row := range report.Rows {
+		statuses = append(statuses, row.Status)
+	}
+	return statuses
+}
+
+func beerNames(bs []beers.Beer) []string {
+	names := make([]string, 0, len(bs))
+	for _, b := range bs {
+		names = append(names, b.Name)
+	}
+	return names
+}
+
+type listParamsSpy struct {
+	ServiceMockOk
+	onList func(params *beers.BeerListParameters)
+}
+
+func (s *listParamsSpy) List(_ context.Context, params *beers.BeerListParameters) (*beers.BeerPage, error) {
+	s.onList(params)
+	return &beers.BeerPage{}, nil
+}
 
 func beerMock() beers.Beer {
 	return beers.Beer{
@@ -194,7 +707,7 @@ func beerMock() beers.Beer {
 		Name:      "GoldenMock",
 		Brewery:   "MockingBrewery",
 		Country:   "ChileMock",
-		Price:     2.5,
+		Price:     money.RequireFromString("2.5"),
 		Currency:  "MCK",
 	}
 }
@@ -205,7 +718,7 @@ func specificPriceBeerMock() beers.Beer {
 		Name:      "Calafate",
 		Brewery:   "Austral",
 		Country:   "ChileMock",
-		Price:     1500,
+		Price:     money.NewFromInt(1500),
 		Currency:  "CLP",
 	}
 }
@@ -216,40 +729,53 @@ func duplicatedbeerMock() beers.Beer {
 	}
 }
 
-func mockBrokenDB() {
+func mockBrokenDB() *gorm.DB {
 	mockDB, _, _ := sqlmock.New()
-	db.Gorm, _ = gorm.Open(mysql.New(mysql.Config{Conn: mockDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+	g, _ := gorm.Open(mysql.New(mysql.Config{Conn: mockDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+	return g
 }
 
 func initTestDB() {
 	var err error
-	db.Gorm, err = gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	testDB, err = gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
 	if err != nil {
 		panic(errors.Wrap(err, "failed to connect gorm with mock DB"))
 	}
-	db.Gorm.AutoMigrate(&beers.Beer{})
+	testDB.AutoMigrate(&beers.Beer{})
 }
 
 func clearTestDB() {
-	db.Gorm.Exec("DELETE FROM beers")
+	testDB.Exec("DELETE FROM beers")
 }
 
 type mockLayerOk struct{}
 
-func (l *mockLayerOk) GetCurrency() (*currencylayer.Response, error) {
-	return &currencylayer.Response{
-		Source: "USD",
-		Quotes: map[string]float64{
-			"USDCLP": float64(828.503912),
-			"USDARS": float64(105.356594),
-			"USDEUR": float64(0.873404),
-			"USDUSD": float64(1),
+func (l *mockLayerOk) GetRates(context.Context) (*rates.Rates, error) {
+	return &rates.Rates{
+		Base:      "USD",
+		Source:    "mock",
+		Timestamp: time.Date(2022, 2, 6, 8, 11, 5, 0, time.UTC),
+		Rates: map[string]money.Decimal{
+			"CLP": money.RequireFromString("828.503912"),
+			"ARS": money.RequireFromString("105.356594"),
+			"EUR": money.RequireFromString("0.873404"),
+			"USD": money.RequireFromString("1"),
 		},
 	}, nil
 }
 
+// GetHistoricalRates answers a CLP only snapshot taken at the end of the day.
+func (l *mockLayerOk) GetHistoricalRates(_ context.Context, date time.Time) (*rates.Rates, error) {
+	return &rates.Rates{
+		Base:      "USD",
+		Source:    "mock",
+		Timestamp: date.Add(24*time.Hour - time.Second),
+		Rates:     map[string]money.Decimal{"CLP": money.RequireFromString("750")},
+	}, nil
+}
+
 type mockLayerError struct{}
 
-func (l *mockLayerError) GetCurrency() (*currencylayer.Response, error) {
+func (l *mockLayerError) GetRates(context.Context) (*rates.Rates, error) {
 	return nil, errors.New("error with layer")
 }
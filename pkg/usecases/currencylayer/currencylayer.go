@@ -1,66 +1,192 @@
 package currencylayer
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
-	"go.uber.org/zap"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rgraterol/beers-api/pkg/restclient"
+	"github.com/rgraterol/beers-api/pkg/tracing"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
 )
 
 const (
-	basePath        = "http://api.currencylayer.com"
-	freeTrialURL    = "/live"
-	accessKey       = "c916fbdbdc0e700ccd61560cafc91fe2"
+	DefaultURL      = "api.currencylayer.com"
 	DefaultCurrency = "USD"
-	cacheTTL        = 30
+	DefaultCacheTTL = 30 * time.Second
+	DefaultTimeout  = 10 * time.Second
+	livePath        = "/live"
+	historicalPath  = "/historical"
+	source          = "currencylayer"
 )
-// Raw cache that works, not suited for high concurrency enviroments
-var Saved time.Time
-var RawCache Response
+
+// maxHistoricalDays bounds the days kept by the historical cache.
+const maxHistoricalDays = 366
 
 type CurrencyInterface interface {
-	GetCurrency() (*Response, error)
+	rates.Interface
+	rates.HistoricalInterface
+	GetCurrency(ctx context.Context) (*Response, error)
+}
+
+// Config tells the layer where and how to reach the API, zero fields take their defaults except the Client.
+type Config struct {
+	Client *restclient.Client
+	// URL is the API address, its scheme is replaced according to HTTPS.
+	URL       string
+	AccessKey string
+	// HTTPS is only available on the paid currencylayer plans.
+	HTTPS      bool
+	Timeout    time.Duration
+	CacheTTL   time.Duration
+	StaleGrace time.Duration
 }
 
-type ProductiveLayer struct {}
+// ProductiveLayer queries the currencylayer API behind a rates.Cache.
+type ProductiveLayer struct {
+	config Config
+	once   sync.Once
+	cache  *rates.Cache
+
+	historicalMu sync.Mutex
+	historical   map[string]*Response
+}
 
-var Layer CurrencyInterface
+func NewProductiveLayer(config Config) *ProductiveLayer {
+	l := &ProductiveLayer{config: config}
+	l.getCache()
+	return l
+}
 
 //TODO: improve client to a connection pool for enhanced performance
-func (l *ProductiveLayer) GetCurrency() (*Response, error) {
-	now := time.Now()
-	var resp Response
-	var err error
-	if (Saved == (time.Time{})) || (now.Sub(Saved) > cacheTTL * time.Second) {
-		resp, err = executeRequest()
-		if err != nil {
-			zap.S().Error(err)
-			return nil, err
+func (l *ProductiveLayer) GetCurrency(ctx context.Context) (*Response, error) {
+	r, err := l.GetRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fromRates(r), nil
+}
+
+// GetRates exposes the quotes as provider neutral rates, so the layer can be used as a rates.Interface.
+func (l *ProductiveLayer) GetRates(ctx context.Context) (*rates.Rates, error) {
+	r, err := l.getCache().GetRates(ctx)
+	if err != nil {
+		tracing.Logger(ctx).Error(err)
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetHistoricalRates serves the quotes of the UTC day of date. Quotes of past days never change, so they are
+// cached by day without expiration.
+func (l *ProductiveLayer) GetHistoricalRates(ctx context.Context, date time.Time) (*rates.Rates, error) {
+	day := rates.Day(date).Format(rates.DateLayout)
+	l.historicalMu.Lock()
+	resp, ok := l.historical[day]
+	l.historicalMu.Unlock()
+	if ok {
+		return resp.toRates(), nil
+	}
+
+	fetched, err := l.request(ctx, l.getURL(historicalPath) + "&date=" + day)
+	if err != nil {
+		tracing.Logger(ctx).Error(err)
+		return nil, err
+	}
+	l.historicalMu.Lock()
+	if l.historical == nil {
+		l.historical = make(map[string]*Response)
+	}
+	if len(l.historical) >= maxHistoricalDays {
+		for evicted := range l.historical {
+			delete(l.historical, evicted)
+			break
 		}
-		RawCache = resp
-		Saved = time.Now()
-	} else {
-		resp = RawCache
 	}
-	return &resp, nil
+	l.historical[day] = &fetched
+	l.historicalMu.Unlock()
+	return fetched.toRates(), nil
 }
 
-func executeRequest() (Response, error) {
+// Stats reports the hits, misses and age of the quotes cache.
+func (l *ProductiveLayer) Stats() rates.CacheStats {
+	return l.getCache().Stats()
+}
+
+func (l *ProductiveLayer) getCache() *rates.Cache {
+	l.once.Do(func() {
+		ttl := l.config.CacheTTL
+		if ttl <= 0 {
+			ttl = DefaultCacheTTL
+		}
+		l.cache = rates.NewCache(rates.ProviderFunc(l.executeRequest), ttl, l.config.StaleGrace, 0)
+	})
+	return l.cache
+}
+
+// executeRequest is not bounded by the cache, request already takes at most the configured timeout.
+func (l *ProductiveLayer) executeRequest(ctx context.Context) (*rates.Rates, error) {
+	resp, err := l.request(ctx, l.getURL(livePath))
+	if err != nil {
+		return nil, err
+	}
+	return resp.toRates(), nil
+}
+
+// request only returns successful responses, error payloads become an *APIError. It takes at most the configured
+// timeout, or less when ctx is done earlier.
+func (l *ProductiveLayer) request(ctx context.Context, address string) (Response, error) {
+	timeout := l.config.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	var resp Response
-	res, err := restclient.Get(getURL())
+	res, err := l.config.Client.Get(ctx, address)
 	if err != nil {
-		return Response{}, err
+		return Response{}, withoutURL(err)
 	}
+	defer res.Body.Close()
 	err = json.NewDecoder(res.Body).Decode(&resp)
 	if err != nil {
 		return Response{}, errors.Wrap(err, "cannot get currency layer API")
 	}
+	if !resp.Success {
+		if resp.Error == nil {
+			return Response{}, &APIError{Info: "unsuccessful response with status " + strconv.Itoa(res.StatusCode)}
+		}
+		return Response{}, resp.Error
+	}
 	return resp, nil
 }
 
-func getURL() string {
-	return fmt.Sprintf(basePath+freeTrialURL+ "?access_key=%s", accessKey)
+// withoutURL drops the address of the transport errors, its query string holds the access key.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return errors.Wrap(urlErr.Err, "cannot reach currency layer API")
+	}
+	return err
+}
+
+func (l *ProductiveLayer) getURL(path string) string {
+	address := l.config.URL
+	if address == "" {
+		address = DefaultURL
+	}
+	if i := strings.Index(address, "://"); i >= 0 {
+		address = address[i+3:]
+	}
+	scheme := "http://"
+	if l.config.HTTPS {
+		scheme = "https://"
+	}
+	return scheme + strings.TrimSuffix(address, "/") + path + "?access_key=" + url.QueryEscape(l.config.AccessKey)
 }
@@ -1,38 +1,35 @@
 package currencylayer_test
 
 import (
+	"context"
 	"bytes"
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/rgraterol/beers-api/pkg/restclient"
 	"github.com/rgraterol/beers-api/pkg/usecases/currencylayer"
 	"github.com/stretchr/testify/assert"
 )
 
-func init() {
-	restclient.Client = &restclient.MockClient{}
-}
-
-const jsonMock = `{"source":"USD","quotes": {"USDARS": 105.356594,"USDCLP": 828.503912,"USDEUR":0.873404,"USDUSD":1}}`
+const jsonMock = `{"success":true,"source":"USD","quotes": {"USDARS": 105.356594,"USDCLP": 828.503912,"USDEUR":0.873404,"USDUSD":1}}`
 const errorMock = `{"success": false,"error": {"code": 101}}`
-
-func init()  {
-	currencylayer.Layer = &currencylayer.ProductiveLayer{}
-}
+const historicalMock = `{"success":true,"historical":true,"date":"2021-12-24","timestamp":1640390399,"source":"USD","quotes":{"USDCLP":850.5}}`
+const quotaMock = `{"success":false,"error":{"code":104,"type":"usage_limit_reached","info":"Your monthly usage limit has been reached."}}`
 
 func TestGetCurrencyLayerError(t *testing.T) {
 	// create a new reader with that JSON
 	r := ioutil.NopCloser(bytes.NewReader([]byte(errorMock)))
-	restclient.GetDoFuncMock = func(*http.Request) (*http.Response, error) {
+	client := restclient.NewMock(func(*http.Request) (*http.Response, error) {
 		return &http.Response{
 			StatusCode: 400,
 			Body:       r,
 		}, errors.New("quota exceded")
-	}
-	resp, err := currencylayer.Layer.GetCurrency()
+	})
+	resp, err := currencylayer.NewProductiveLayer(currencylayer.Config{Client: client}).GetCurrency(context.Background())
 	assert.Nil(t, resp)
 	assert.NotNil(t, err)
 }
@@ -40,14 +37,138 @@ func TestGetCurrencyLayerError(t *testing.T) {
 func TestGetCurrencyLayerOk(t *testing.T) {
 	// create a new reader with that JSON
 	r := ioutil.NopCloser(bytes.NewReader([]byte(jsonMock)))
-	restclient.GetDoFuncMock = func(*http.Request) (*http.Response, error) {
+	client := restclient.NewMock(func(*http.Request) (*http.Response, error) {
 		return &http.Response{
 			StatusCode: 200,
 			Body:       r,
 		}, nil
-	}
-	resp, err := currencylayer.Layer.GetCurrency()
+	})
+	resp, err := currencylayer.NewProductiveLayer(currencylayer.Config{Client: client}).GetCurrency(context.Background())
 	assert.NotNil(t, resp)
 	assert.Nil(t, err)
-	assert.Equal(t, float64(1), resp.Quotes["USDUSD"])
+	assert.Equal(t, "1", resp.Quotes["USDUSD"].String())
+}
+
+func TestGetRatesLayerOk(t *testing.T) {
+	// create a new reader with that JSON
+	r := ioutil.NopCloser(bytes.NewReader([]byte(jsonMock)))
+	client := restclient.NewMock(func(*http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       r,
+		}, nil
+	})
+	resp, err := currencylayer.NewProductiveLayer(currencylayer.Config{Client: client}).GetRates(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "USD", resp.Base)
+	assert.Equal(t, "currencylayer", resp.Source)
+	assert.Equal(t, "828.503912", resp.Rate("CLP").String())
+}
+
+func TestGetRatesLayerQuotaPayload(t *testing.T) {
+	// Given
+	client := restclient.NewMock(func(*http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(quotaMock))),
+		}, nil
+	})
+	layer := currencylayer.NewProductiveLayer(currencylayer.Config{CacheTTL: time.Minute, Client: client})
+	// When
+	resp, err := layer.GetRates(context.Background())
+	// Then
+	assert.Nil(t, resp)
+	assert.True(t, errors.Is(err, currencylayer.QuotaExceededError))
+	var apiErr *currencylayer.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode())
+	assert.Contains(t, err.Error(), "monthly usage limit")
+}
+
+func TestGetRatesLayerErrorPayloadIsNotCached(t *testing.T) {
+	// Given
+	body := errorMock
+	client := restclient.NewMock(func(*http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil
+	})
+	layer := currencylayer.NewProductiveLayer(currencylayer.Config{CacheTTL: time.Minute, Client: client})
+	_, err := layer.GetRates(context.Background())
+	assert.True(t, errors.Is(err, currencylayer.AccessKeyError))
+	body = jsonMock
+	// When
+	resp, err := layer.GetRates(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "105.356594", resp.Rate("ARS").String())
+}
+
+func TestAPIErrorStatusCodes(t *testing.T) {
+	assert.Equal(t, http.StatusBadGateway, (&currencylayer.APIError{Code: 101}).StatusCode())
+	assert.Equal(t, http.StatusBadGateway, (&currencylayer.APIError{Code: 105}).StatusCode())
+	assert.Equal(t, http.StatusServiceUnavailable, (&currencylayer.APIError{Code: 104}).StatusCode())
+	assert.Equal(t, http.StatusBadRequest, (&currencylayer.APIError{Code: 202}).StatusCode())
+	assert.Equal(t, http.StatusBadGateway, (&currencylayer.APIError{Code: 999}).StatusCode())
+}
+
+func TestGetRatesLayerConfiguredURL(t *testing.T) {
+	// Given
+	var requested string
+	client := restclient.NewMock(func(req *http.Request) (*http.Response, error) {
+		requested = req.URL.String()
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(jsonMock))),
+		}, nil
+	})
+	layer := currencylayer.NewProductiveLayer(currencylayer.Config{
+		URL:       "http://rates.example.com/",
+		AccessKey: "secret key",
+		HTTPS:     true,
+		Client:    client,
+	})
+	// When
+	_, err := layer.GetRates(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "https://rates.example.com/live?access_key=secret+key", requested)
+}
+
+func TestGetHistoricalRatesCachedByDay(t *testing.T) {
+	// Given
+	var requested []string
+	client := restclient.NewMock(func(req *http.Request) (*http.Response, error) {
+		requested = append(requested, req.URL.String())
+		return &http.Response{
+			StatusCode: 200,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(historicalMock))),
+		}, nil
+	})
+	layer := currencylayer.NewProductiveLayer(currencylayer.Config{AccessKey: "key", Client: client})
+	// When
+	first, err := layer.GetHistoricalRates(context.Background(), time.Date(2021, 12, 24, 9, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	second, err := layer.GetHistoricalRates(context.Background(), time.Date(2021, 12, 24, 18, 0, 0, 0, time.UTC))
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"http://api.currencylayer.com/historical?access_key=key&date=2021-12-24"}, requested)
+	assert.Equal(t, first, second)
+	assert.Equal(t, "850.5", second.Rate("CLP").String())
+	assert.Equal(t, time.Date(2021, 12, 24, 23, 59, 59, 0, time.UTC), second.Timestamp)
+}
+
+func TestGetRatesLayerTransportErrorHidesAccessKey(t *testing.T) {
+	// Given
+	client := restclient.NewMock(func(req *http.Request) (*http.Response, error) {
+		return nil, &url.Error{Op: "Get", URL: req.URL.String(), Err: errors.New("connection refused")}
+	})
+	layer := currencylayer.NewProductiveLayer(currencylayer.Config{AccessKey: "SECRETKEY", Client: client})
+	// When
+	_, err := layer.GetHistoricalRates(context.Background(), time.Date(2021, 12, 24, 0, 0, 0, 0, time.UTC))
+	// Then
+	assert.NotNil(t, err)
+	assert.NotContains(t, err.Error(), "SECRETKEY")
+	assert.Contains(t, err.Error(), "connection refused")
 }
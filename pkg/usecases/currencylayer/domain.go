@@ -1,10 +1,49 @@
 package currencylayer
 
+import (
+	"strings"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
+)
+
 type Response struct {
-	Success   bool               `json:"success"`
-	Terms     string             `json:"terms"`
-	Privacy   string             `json:"privacy"`
-	Timestamp int                `json:"timestamp"`
-	Source    string             `json:"source"`
-	Quotes    map[string]float64 `json:"quotes"`
+	Success    bool                     `json:"success"`
+	Terms      string                   `json:"terms"`
+	Privacy    string                   `json:"privacy"`
+	Timestamp  int                      `json:"timestamp"`
+	Historical bool                     `json:"historical,omitempty"`
+	Date       string                   `json:"date,omitempty"`
+	Source     string                   `json:"source"`
+	Quotes     map[string]money.Decimal `json:"quotes"`
+	Error      *APIError                `json:"error,omitempty"`
+}
+
+// toRates strips the source prefix from the quotes keys, "USDCLP" becomes "CLP".
+func (r *Response) toRates() *rates.Rates {
+	converted := rates.Rates{
+		Base:      r.Source,
+		Timestamp: time.Unix(int64(r.Timestamp), 0).UTC(),
+		Source:    source,
+		Rates:     make(map[string]money.Decimal, len(r.Quotes)),
+	}
+	for pair, quote := range r.Quotes {
+		converted.Rates[strings.TrimPrefix(pair, r.Source)] = quote
+	}
+	return &converted
+}
+
+// fromRates keys the rates back as quotes of their base, "CLP" becomes "USDCLP".
+func fromRates(r *rates.Rates) *Response {
+	resp := Response{
+		Success:   true,
+		Timestamp: int(r.Timestamp.Unix()),
+		Source:    r.Base,
+		Quotes:    make(map[string]money.Decimal, len(r.Rates)),
+	}
+	for currency, rate := range r.Rates {
+		resp.Quotes[r.Base+currency] = rate
+	}
+	return &resp
 }
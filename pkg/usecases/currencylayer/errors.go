@@ -0,0 +1,69 @@
+package currencylayer
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// Kinds of the errors answered by currencylayer, match them with errors.Is.
+var (
+	AccessKeyError           = errors.New("invalid currencylayer access key or inactive account")
+	UnsupportedFunctionError = errors.New("function not supported by the currencylayer plan")
+	QuotaExceededError       = errors.New("currencylayer usage limit reached")
+	InvalidCurrencyError     = errors.New("invalid currency for currencylayer")
+	InvalidDateError         = errors.New("invalid date for currencylayer")
+	UnexpectedError          = errors.New("unexpected currencylayer error")
+)
+
+// errorKinds maps the documented currencylayer error codes to their kind.
+var errorKinds = map[int]error{
+	101: AccessKeyError,
+	102: AccessKeyError,
+	103: UnsupportedFunctionError,
+	104: QuotaExceededError,
+	105: UnsupportedFunctionError,
+	201: InvalidCurrencyError,
+	202: InvalidCurrencyError,
+	301: InvalidDateError,
+	302: InvalidDateError,
+}
+
+// APIError is the error object of an unsuccessful currencylayer response.
+type APIError struct {
+	Code int    `json:"code"`
+	Type string `json:"type"`
+	Info string `json:"info"`
+}
+
+func (e *APIError) Error() string {
+	detail := e.Info
+	if detail == "" {
+		detail = e.Type
+	}
+	return fmt.Sprintf("%s (code %d): %s", e.kind().Error(), e.Code, detail)
+}
+
+// StatusCode is the status answered to our clients, upstream failures are not their fault unless they sent
+// a currency or date currencylayer doesn't know.
+func (e *APIError) StatusCode() int {
+	switch e.kind() {
+	case QuotaExceededError:
+		return http.StatusServiceUnavailable
+	case InvalidCurrencyError, InvalidDateError:
+		return http.StatusBadRequest
+	}
+	return http.StatusBadGateway
+}
+
+func (e *APIError) Is(target error) bool {
+	return e.kind() == target
+}
+
+func (e *APIError) kind() error {
+	if kind, ok := errorKinds[e.Code]; ok {
+		return kind
+	}
+	return UnexpectedError
+}
@@ -0,0 +1,98 @@
+package rates
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var CircuitOpenError = errors.New("the exchange rates circuit is open")
+
+const (
+	breakerClosed = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// Breaker stops calling a failing provider. After Threshold consecutive failures it opens and fails fast for
+// OpenTimeout, then lets a single trial call through to decide whether to close again. Calls cancelled by their
+// caller are not failures.
+type Breaker struct {
+	Provider    Interface
+	Threshold   int
+	OpenTimeout time.Duration
+
+	mu       sync.Mutex
+	state    int
+	failures int
+	openedAt time.Time
+}
+
+func NewBreaker(provider Interface, threshold int, openTimeout time.Duration) *Breaker {
+	return &Breaker{Provider: provider, Threshold: threshold, OpenTimeout: openTimeout}
+}
+
+func (b *Breaker) GetRates(ctx context.Context) (*Rates, error) {
+	if !b.allow() {
+		return nil, CircuitOpenError
+	}
+	r, err := b.Provider.GetRates(ctx)
+	b.record(err)
+	return r, err
+}
+
+// GetHistoricalRates shares the breaker state with GetRates, providers without historical rates fail without
+// counting as a failure.
+func (b *Breaker) GetHistoricalRates(ctx context.Context, date time.Time) (*Rates, error) {
+	historical, ok := b.Provider.(HistoricalInterface)
+	if !ok {
+		return nil, HistoricalUnsupportedError
+	}
+	if !b.allow() {
+		return nil, CircuitOpenError
+	}
+	r, err := historical.GetHistoricalRates(ctx, date)
+	b.record(err)
+	return r, err
+}
+
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.OpenTimeout {
+			return false
+		}
+		b.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		// Only the trial call goes through until it finishes
+		return false
+	}
+	return true
+}
+
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if errors.Is(err, context.Canceled) {
+		// The caller went away, which tells nothing about the provider, so a trial call is let through again
+		if b.state == breakerHalfOpen {
+			b.state = breakerOpen
+		}
+		return
+	}
+	if err == nil {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.Threshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
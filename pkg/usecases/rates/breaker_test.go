@@ -0,0 +1,95 @@
+package rates_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	// Given
+	provider := &providerMock{err: errors.New("quota exceeded")}
+	b := rates.NewBreaker(provider, 2, time.Minute)
+	// When
+	_, err1 := b.GetRates(context.Background())
+	_, err2 := b.GetRates(context.Background())
+	_, err3 := b.GetRates(context.Background())
+	// Then
+	assert.Equal(t, "quota exceeded", err1.Error())
+	assert.Equal(t, "quota exceeded", err2.Error())
+	assert.Equal(t, rates.CircuitOpenError, err3)
+	assert.Equal(t, 2, provider.calls)
+}
+
+func TestBreakerClosesAfterSuccessfulTrial(t *testing.T) {
+	// Given
+	provider := &providerMock{err: errors.New("quota exceeded")}
+	b := rates.NewBreaker(provider, 1, 10*time.Millisecond)
+	_, err := b.GetRates(context.Background())
+	assert.NotNil(t, err)
+	_, err = b.GetRates(context.Background())
+	assert.Equal(t, rates.CircuitOpenError, err)
+	time.Sleep(20 * time.Millisecond)
+	provider.err = nil
+	// When
+	r, err := b.GetRates(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assert.NotNil(t, r)
+	_, err = b.GetRates(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, provider.calls)
+}
+
+func TestBreakerReopensAfterFailedTrial(t *testing.T) {
+	// Given
+	provider := &providerMock{err: errors.New("quota exceeded")}
+	b := rates.NewBreaker(provider, 3, 10*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		_, _ = b.GetRates(context.Background())
+	}
+	time.Sleep(20 * time.Millisecond)
+	// When
+	_, trialErr := b.GetRates(context.Background())
+	_, err := b.GetRates(context.Background())
+	// Then
+	assert.Equal(t, "quota exceeded", trialErr.Error())
+	assert.Equal(t, rates.CircuitOpenError, err)
+	assert.Equal(t, 4, provider.calls)
+}
+
+func TestBreakerIgnoresCancelledCalls(t *testing.T) {
+	// Given
+	provider := &providerMock{err: fmt.Errorf("cannot get ECB rates: %w", context.Canceled)}
+	b := rates.NewBreaker(provider, 1, time.Minute)
+	// When
+	_, err1 := b.GetRates(context.Background())
+	_, err2 := b.GetRates(context.Background())
+	// Then
+	assert.True(t, errors.Is(err1, context.Canceled))
+	assert.True(t, errors.Is(err2, context.Canceled))
+	assert.Equal(t, 2, provider.calls)
+}
+
+type providerMock struct {
+	rates *rates.Rates
+	err   error
+	calls int
+}
+
+func (p *providerMock) GetRates(context.Context) (*rates.Rates, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.rates != nil {
+		return p.rates, nil
+	}
+	return &rates.Rates{Base: "USD", Source: "mock", Rates: map[string]money.Decimal{"CLP": money.RequireFromString("828.503912")}}, nil
+}
@@ -0,0 +1,151 @@
+package rates
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/tracing"
+)
+
+// Cache keeps the last rates of a provider for TTL. Concurrent refreshes are collapsed into a single call, and once
+// the TTL expires the stale rates keep being served during Grace while they are refreshed in background. Refreshes
+// are shared by every waiting caller, so they keep the trace of the caller that started them but not its
+// cancellation, they are cut after Timeout instead so a stalled provider never holds the later refreshes.
+// Failures are never stored, so the previous rates stay available.
+// It only caches the current rates, it is not a HistoricalInterface.
+type Cache struct {
+	Provider Interface
+	TTL      time.Duration
+	Grace    time.Duration
+	Timeout  time.Duration
+
+	mu       sync.Mutex
+	value    *Rates
+	saved    time.Time
+	inflight *cacheRefresh
+
+	hits      uint64
+	staleHits uint64
+	misses    uint64
+	refreshes uint64
+	failures  uint64
+}
+
+// CacheStats is a snapshot of the cache counters, Age is zero while the cache is empty.
+type CacheStats struct {
+	Hits      uint64        `json:"hits"`
+	StaleHits uint64        `json:"stale_hits"`
+	Misses    uint64        `json:"misses"`
+	Refreshes uint64        `json:"refreshes"`
+	Failures  uint64        `json:"failures"`
+	Saved     time.Time     `json:"saved"`
+	Age       time.Duration `json:"age"`
+}
+
+type cacheRefresh struct {
+	done  chan struct{}
+	value *Rates
+	err   error
+}
+
+func NewCache(provider Interface, ttl time.Duration, grace time.Duration, timeout time.Duration) *Cache {
+	return &Cache{Provider: provider, TTL: ttl, Grace: grace, Timeout: timeout}
+}
+
+// GetRates stops waiting for a refresh when ctx is done, the refresh goes on for the other callers.
+func (c *Cache) GetRates(ctx context.Context) (*Rates, error) {
+	c.mu.Lock()
+	if c.value != nil {
+		age := time.Since(c.saved)
+		if age <= c.TTL {
+			r := c.value.copy()
+			c.mu.Unlock()
+			atomic.AddUint64(&c.hits, 1)
+			return r, nil
+		}
+		if age <= c.TTL+c.Grace {
+			r := c.value.copy()
+			c.startRefresh(ctx)
+			c.mu.Unlock()
+			atomic.AddUint64(&c.staleHits, 1)
+			return r, nil
+		}
+	}
+	call := c.startRefresh(ctx)
+	c.mu.Unlock()
+	atomic.AddUint64(&c.misses, 1)
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if call.err != nil {
+		return nil, call.err
+	}
+	return call.value.copy(), nil
+}
+
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	saved := c.saved
+	c.mu.Unlock()
+	stats := CacheStats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		StaleHits: atomic.LoadUint64(&c.staleHits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Refreshes: atomic.LoadUint64(&c.refreshes),
+		Failures:  atomic.LoadUint64(&c.failures),
+		Saved:     saved,
+	}
+	if !saved.IsZero() {
+		stats.Age = time.Since(saved)
+	}
+	return stats
+}
+
+// startRefresh joins the refresh in flight or starts a new one, c.mu must be held.
+func (c *Cache) startRefresh(ctx context.Context) *cacheRefresh {
+	if c.inflight != nil {
+		return c.inflight
+	}
+	call := &cacheRefresh{done: make(chan struct{})}
+	c.inflight = call
+	go c.refresh(tracing.Detach(ctx), call)
+	return call
+}
+
+func (c *Cache) refresh(ctx context.Context, call *cacheRefresh) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	r, err := c.Provider.GetRates(ctx)
+	c.mu.Lock()
+	if err != nil {
+		atomic.AddUint64(&c.failures, 1)
+		call.err = err
+	} else {
+		atomic.AddUint64(&c.refreshes, 1)
+		c.value = r
+		c.saved = time.Now()
+		call.value = r
+	}
+	c.inflight = nil
+	c.mu.Unlock()
+	close(call.done)
+}
+
+// copy keeps the callers from changing the cached rates.
+func (r *Rates) copy() *Rates {
+	copied := *r
+	copied.Rates = make(map[string]money.Decimal, len(r.Rates))
+	for currency, rate := range r.Rates {
+		copied.Rates[currency] = rate
+	}
+	return &copied
+}
@@ -0,0 +1,193 @@
+package rates_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheServesFreshRates(t *testing.T) {
+	// Given
+	provider := &providerMock{}
+	c := rates.NewCache(provider, time.Minute, 0, 0)
+	first, err := c.GetRates(context.Background())
+	assert.Nil(t, err)
+	first.Rates["CLP"] = money.NewFromInt(1)
+	// When
+	second, err := c.GetRates(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, 1, provider.calls)
+	assert.Equal(t, "828.503912", second.Rates["CLP"].String())
+}
+
+func TestCacheRefreshesExpiredRates(t *testing.T) {
+	// Given
+	provider := &providerMock{}
+	c := rates.NewCache(provider, time.Millisecond, 0, 0)
+	_, err := c.GetRates(context.Background())
+	assert.Nil(t, err)
+	time.Sleep(5 * time.Millisecond)
+	provider.err = errors.New("ECB is down")
+	// When
+	_, err = c.GetRates(context.Background())
+	// Then
+	assert.EqualError(t, err, "ECB is down")
+	assert.Equal(t, 2, provider.calls)
+}
+
+func TestCacheServesStaleRatesWithinGrace(t *testing.T) {
+	// Given
+	c := rates.NewCache(&providerMock{}, time.Millisecond, time.Minute, 0)
+	_, err := c.GetRates(context.Background())
+	assert.Nil(t, err)
+	time.Sleep(5 * time.Millisecond)
+	c.Provider = &providerMock{err: errors.New("ECB is down")}
+	// When
+	r, err := c.GetRates(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "mock", r.Source)
+}
+
+func TestCacheStalledProviderReleasesTheRefresh(t *testing.T) {
+	// Given
+	stalled := &stalledProviderMock{}
+	c := rates.NewCache(stalled, time.Minute, 0, 10*time.Millisecond)
+	_, err := c.GetRates(context.Background())
+	assert.Equal(t, context.DeadlineExceeded, err)
+	c.Provider = &providerMock{}
+	// When
+	r, err := c.GetRates(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "mock", r.Source)
+}
+
+// stalledProviderMock never answers until its ctx is done.
+type stalledProviderMock struct{}
+
+func (p *stalledProviderMock) GetRates(ctx context.Context) (*rates.Rates, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestCacheHitAfterMiss(t *testing.T) {
+	// Given
+	var calls int32
+	c := rates.NewCache(countingProvider(&calls, nil), time.Minute, 0, 0)
+	// When
+	first, err := c.GetRates(context.Background())
+	assert.Nil(t, err)
+	second, err := c.GetRates(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, first.Rates, second.Rates)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	stats := c.Stats()
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.True(t, stats.Age > 0)
+}
+
+func TestCacheCollapsesConcurrentRefreshes(t *testing.T) {
+	// Given
+	var calls int32
+	release := make(chan struct{})
+	c := rates.NewCache(countingProvider(&calls, release), time.Minute, 0, 0)
+	var wg sync.WaitGroup
+	// When
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := c.GetRates(context.Background())
+			assert.Nil(t, err)
+			assert.NotNil(t, r)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	// Then
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, uint64(20), c.Stats().Misses)
+}
+
+func TestCacheServesStaleWhileRevalidating(t *testing.T) {
+	// Given
+	var calls int32
+	c := rates.NewCache(countingProvider(&calls, nil), 10*time.Millisecond, time.Minute, 0)
+	_, err := c.GetRates(context.Background())
+	assert.Nil(t, err)
+	time.Sleep(20 * time.Millisecond)
+	// When
+	r, err := c.GetRates(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assert.NotNil(t, r)
+	assert.Equal(t, uint64(1), c.Stats().StaleHits)
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&calls) == 2 && c.Stats().Refreshes == 2
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestCacheDoesNotStoreFailures(t *testing.T) {
+	// Given
+	var calls int32
+	c := rates.NewCache(rates.ProviderFunc(func(context.Context) (*rates.Rates, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return nil, errors.New("quota exceeded")
+		}
+		return &rates.Rates{Base: "USD", Rates: map[string]money.Decimal{"CLP": money.RequireFromString("828.503912")}}, nil
+	}), time.Minute, 0, 0)
+	// When
+	failed, err := c.GetRates(context.Background())
+	assert.Nil(t, failed)
+	assert.NotNil(t, err)
+	r, err := c.GetRates(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "828.503912", r.Rate("CLP").String())
+	assert.Equal(t, uint64(1), c.Stats().Failures)
+}
+
+func TestCacheStopsWaitingWhenContextIsDone(t *testing.T) {
+	// Given
+	var calls int32
+	release := make(chan struct{})
+	c := rates.NewCache(countingProvider(&calls, release), time.Minute, 0, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	// When
+	r, err := c.GetRates(ctx)
+	close(release)
+	// Then
+	assert.Nil(t, r)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Eventually(t, func() bool {
+		return c.Stats().Refreshes == 1
+	}, time.Second, 5*time.Millisecond)
+	cached, err := c.GetRates(context.Background())
+	assert.Nil(t, err)
+	assert.NotNil(t, cached)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// countingProvider answers fixed rates, waiting for release when it is not nil.
+func countingProvider(calls *int32, release chan struct{}) rates.Interface {
+	return rates.ProviderFunc(func(context.Context) (*rates.Rates, error) {
+		atomic.AddInt32(calls, 1)
+		if release != nil {
+			<-release
+		}
+		return &rates.Rates{Base: "USD", Rates: map[string]money.Decimal{"CLP": money.RequireFromString("828.503912")}}, nil
+	})
+}
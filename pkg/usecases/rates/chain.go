@@ -0,0 +1,78 @@
+package rates
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/pkg/tracing"
+)
+
+// Recorder keeps the rates served by the primary provider, so they can be used as fallback later on.
+type Recorder interface {
+	Save(ctx context.Context, r *Rates) error
+}
+
+// Chain asks its providers in order and answers with the first one that succeeds. Rates coming from the
+// primary provider, the first one, are handed to the Recorder when there is one.
+type Chain struct {
+	Providers []Interface
+	Recorder  Recorder
+}
+
+// GetRates wraps the primary provider error when every provider fails, so its cause is kept. The next providers
+// are not asked once ctx is done.
+func (c *Chain) GetRates(ctx context.Context) (*Rates, error) {
+	var primaryErr error
+	for i, provider := range c.Providers {
+		r, err := provider.GetRates(ctx)
+		if err != nil {
+			tracing.Logger(ctx).Warn("exchange rates provider failed, trying the next one ", err)
+			if i == 0 {
+				primaryErr = err
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		if i == 0 && c.Recorder != nil {
+			if err = c.Recorder.Save(ctx, r); err != nil {
+				tracing.Logger(ctx).Error("cannot record the last known good rates ", err)
+			}
+		}
+		return r, nil
+	}
+	return nil, chainError(primaryErr)
+}
+
+// GetHistoricalRates asks the providers that know past rates, nothing is recorded since the Recorder only
+// keeps the latest rates.
+func (c *Chain) GetHistoricalRates(ctx context.Context, date time.Time) (*Rates, error) {
+	var primaryErr error
+	for i, provider := range c.Providers {
+		r, err := HistoricalRates(ctx, provider, date)
+		if err != nil {
+			if err != HistoricalUnsupportedError {
+				tracing.Logger(ctx).Warn("historical exchange rates provider failed, trying the next one ", err)
+			}
+			if i == 0 {
+				primaryErr = err
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		return r, nil
+	}
+	return nil, chainError(primaryErr)
+}
+
+// chainError wraps the primary provider error only, the failures of every provider were logged as they came.
+func chainError(primaryErr error) error {
+	if primaryErr == nil {
+		return errors.New("there are no exchange rates providers")
+	}
+	return errors.Wrap(primaryErr, "every exchange rates provider failed")
+}
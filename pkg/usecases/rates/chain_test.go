@@ -0,0 +1,137 @@
+package rates_test
+
+import (
+	"context"
+	"errors"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChainRecordsPrimaryRates(t *testing.T) {
+	// Given
+	store := rates.NewDBStore(initRatesTestDB(t))
+	primary := &providerMock{rates: ratesMock(time.Date(2022, 2, 6, 8, 0, 0, 0, time.UTC))}
+	chain := rates.Chain{Providers: []rates.Interface{primary, store}, Recorder: store}
+	// When
+	r, err := chain.GetRates(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "currencylayer", r.Source)
+	saved, err := store.GetRates(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "database", saved.Source)
+	assert.Equal(t, primary.rates.Timestamp, saved.Timestamp)
+	assert.Equal(t, primary.rates.Rates, saved.Rates)
+}
+
+func TestChainFallsBackToLastKnownGoodRates(t *testing.T) {
+	// Given
+	store := rates.NewDBStore(initRatesTestDB(t))
+	assert.Nil(t, store.Save(context.Background(), ratesMock(time.Date(2022, 2, 5, 8, 0, 0, 0, time.UTC))))
+	assert.Nil(t, store.Save(context.Background(), ratesMock(time.Date(2022, 2, 6, 8, 0, 0, 0, time.UTC))))
+	chain := rates.Chain{
+		Providers: []rates.Interface{&providerMock{err: errors.New("quota exceeded")}, store},
+		Recorder:  store,
+	}
+	// When
+	r, err := chain.GetRates(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "database", r.Source)
+	assert.Equal(t, time.Date(2022, 2, 6, 8, 0, 0, 0, time.UTC), r.Timestamp)
+	assert.Equal(t, "828.503912", r.Rate("CLP").String())
+}
+
+func TestStoreSavesEachSnapshotOnceAcrossInstances(t *testing.T) {
+	// Given
+	g := initRatesTestDB(t)
+	first, second := rates.NewDBStore(g), rates.NewDBStore(g)
+	snapshot := ratesMock(time.Date(2022, 2, 6, 8, 0, 0, 0, time.UTC))
+	assert.Nil(t, first.Save(context.Background(), snapshot))
+	snapshot.Rates["EUR"] = money.RequireFromString("0.876")
+	// When
+	err := second.Save(context.Background(), snapshot)
+	// Then
+	assert.Nil(t, err)
+	var count int64
+	assert.Nil(t, g.Model(&rates.ExchangeRate{}).Count(&count).Error)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestChainEveryProviderFailed(t *testing.T) {
+	// Given
+	g := initRatesTestDB(t)
+	chain := rates.Chain{Providers: []rates.Interface{
+		&providerMock{err: errors.New("quota exceeded")},
+		rates.NewDBStore(g),
+	}}
+	// When
+	r, err := chain.GetRates(context.Background())
+	// Then
+	assert.Nil(t, r)
+	assert.Equal(t, "every exchange rates provider failed: quota exceeded", err.Error())
+}
+
+func TestChainKeepsPrimaryErrorCause(t *testing.T) {
+	// Given
+	primaryErr := errors.New("quota exceeded")
+	chain := rates.Chain{Providers: []rates.Interface{
+		&providerMock{err: primaryErr},
+		&providerMock{err: errors.New("static rates missing")},
+	}}
+	// When
+	_, err := chain.GetRates(context.Background())
+	// Then
+	assert.True(t, errors.Is(err, primaryErr))
+}
+
+func TestChainHistoricalRatesSkipUnsupportedProviders(t *testing.T) {
+	// Given
+	store := rates.NewDBStore(initRatesTestDB(t))
+	assert.Nil(t, store.Save(context.Background(), ratesMock(time.Date(2022, 2, 5, 8, 0, 0, 0, time.UTC))))
+	assert.Nil(t, store.Save(context.Background(), ratesMock(time.Date(2022, 2, 5, 20, 0, 0, 0, time.UTC))))
+	assert.Nil(t, store.Save(context.Background(), ratesMock(time.Date(2022, 2, 6, 8, 0, 0, 0, time.UTC))))
+	chain := rates.Chain{Providers: []rates.Interface{rates.NewBreaker(&providerMock{}, 1, time.Minute), store}}
+	// When
+	r, err := chain.GetHistoricalRates(context.Background(), time.Date(2022, 2, 5, 13, 0, 0, 0, time.UTC))
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "database", r.Source)
+	assert.Equal(t, time.Date(2022, 2, 5, 20, 0, 0, 0, time.UTC), r.Timestamp)
+}
+
+func TestChainHistoricalRatesMissingDay(t *testing.T) {
+	// Given
+	store := rates.NewDBStore(initRatesTestDB(t))
+	assert.Nil(t, store.Save(context.Background(), ratesMock(time.Date(2022, 2, 6, 8, 0, 0, 0, time.UTC))))
+	chain := rates.Chain{Providers: []rates.Interface{&providerMock{}, store}}
+	// When
+	r, err := chain.GetHistoricalRates(context.Background(), time.Date(2022, 2, 4, 0, 0, 0, 0, time.UTC))
+	// Then
+	assert.Nil(t, r)
+	assert.True(t, errors.Is(err, rates.HistoricalUnsupportedError))
+	assert.NotContains(t, err.Error(), "cannot find saved exchange rates")
+}
+
+func ratesMock(timestamp time.Time) *rates.Rates {
+	return &rates.Rates{
+		Base:      "USD",
+		Timestamp: timestamp,
+		Source:    "currencylayer",
+		Rates:     map[string]money.Decimal{"CLP": money.RequireFromString("828.503912"), "ARS": money.RequireFromString("105.356594")},
+	}
+}
+
+func initRatesTestDB(t *testing.T) *gorm.DB {
+	g, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, g.Migrator().DropTable(&rates.ExchangeRate{}))
+	assert.Nil(t, g.AutoMigrate(&rates.ExchangeRate{}))
+	return g
+}
@@ -0,0 +1,50 @@
+package rates
+
+import (
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+)
+
+// DateLayout is the format of the days asked for historical rates.
+const DateLayout = "2006-01-02"
+
+// Rates are the exchange rates of a provider against its Base currency: one unit of Base buys Rates[code] units
+// of code. They are kept as decimals from the provider answer onwards, so no digit is lost on the conversions.
+type Rates struct {
+	Base      string                   `json:"base" yaml:"base"`
+	Timestamp time.Time                `json:"timestamp" yaml:"timestamp"`
+	Source    string                   `json:"source" yaml:"-"`
+	Rates     map[string]money.Decimal `json:"rates" yaml:"rates"`
+}
+
+// Rate returns how many units of currency one unit of Base buys, zero when the currency is unknown.
+func (r *Rates) Rate(currency string) money.Decimal {
+	if currency == r.Base {
+		return money.NewFromInt(1)
+	}
+	return r.Rates[currency]
+}
+
+// HistoryParameters filter the saved rates of Currency between the From and To days, both included.
+type HistoryParameters struct {
+	Currency string    `json:"currency"`
+	From     time.Time `json:"from"`
+	To       time.Time `json:"to"`
+}
+
+// History is the movement of a currency over the saved snapshots, oldest first.
+type History struct {
+	Currency string      `json:"currency"`
+	From     string      `json:"from"`
+	To       string      `json:"to"`
+	Results  []RatePoint `json:"results"`
+}
+
+// RatePoint is the rate of a currency on a snapshot.
+type RatePoint struct {
+	Base    string        `json:"base"`
+	Rate    money.Decimal `json:"rate"`
+	RatedAt time.Time     `json:"rated_at"`
+	Source  string        `json:"source"`
+}
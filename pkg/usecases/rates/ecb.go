@@ -0,0 +1,78 @@
+package rates
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/restclient"
+)
+
+const (
+	ECBDailyURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+	ecbBase     = "EUR"
+	ecbSource   = "ecb"
+	// DefaultECBTimeout bounds the ECB requests without a Timeout.
+	DefaultECBTimeout = 10 * time.Second
+)
+
+// ECBProvider reads the daily euro foreign exchange reference rates published by the European Central Bank.
+type ECBProvider struct {
+	URL     string
+	Client  *restclient.Client
+	Timeout time.Duration
+}
+
+type ecbEnvelope struct {
+	Cube struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string        `xml:"currency,attr"`
+				Rate     money.Decimal `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+func (p *ECBProvider) GetRates(ctx context.Context) (*Rates, error) {
+	url := p.URL
+	if url == "" {
+		url = ECBDailyURL
+	}
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = DefaultECBTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	res, err := p.Client.Get(ctx, url)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get ECB rates")
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New("cannot get ECB rates, status " + strconv.Itoa(res.StatusCode))
+	}
+	var envelope ecbEnvelope
+	if err = xml.NewDecoder(res.Body).Decode(&envelope); err != nil {
+		return nil, errors.Wrap(err, "cannot decode ECB rates")
+	}
+	if len(envelope.Cube.Days) == 0 {
+		return nil, errors.New("ECB rates came empty")
+	}
+	day := envelope.Cube.Days[0]
+	timestamp, err := time.Parse("2006-01-02", day.Time)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid ECB rates date")
+	}
+	r := Rates{Base: ecbBase, Timestamp: timestamp, Source: ecbSource, Rates: make(map[string]money.Decimal)}
+	for _, rate := range day.Rates {
+		r.Rates[rate.Currency] = rate.Rate
+	}
+	return &r, nil
+}
@@ -0,0 +1,74 @@
+package rates
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/refdata"
+	"github.com/rgraterol/beers-api/pkg/responses"
+	"github.com/rgraterol/beers-api/pkg/tracing"
+	"github.com/rgraterol/beers-api/pkg/validation"
+)
+
+const (
+	defaultHistoryDays = 30
+	maxHistoryDays     = 366
+)
+
+// List answers the saved rates of a currency over a range of days, the last 30 by default.
+func List(s HistoryInterface) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params, err := decodeHistoryParams(r)
+		if err != nil {
+			tracing.Logger(r.Context()).Error(err)
+			responses.Error(w, err)
+			return
+		}
+		history, err := s.History(r.Context(), params)
+		if err != nil {
+			responses.Error(w, err)
+			return
+		}
+		responses.OK(w, history)
+	}
+}
+
+// decodeHistoryParams checks every param, the invalid ones are answered together as validation errors. The currency
+// is case insensitive and normalised to its ISO-4217 code.
+func decodeHistoryParams(r *http.Request) (*HistoryParameters, error) {
+	var v validation.Errors
+	q := r.URL.Query()
+	params := HistoryParameters{Currency: q.Get("currency")}
+	if params.Currency == "" {
+		v.Check(validation.NewFieldError("currency", validation.Required, "currency cannot be empty"))
+	} else if currency, ok := refdata.LookupCurrency(params.Currency); ok {
+		params.Currency = currency.Code
+	} else {
+		v.Check(validation.NewFieldError("currency", validation.ISO4217, "invalid currency"))
+	}
+
+	var toErr, fromErr error
+	params.To = Day(time.Now())
+	if to := q.Get("to"); to != "" {
+		if params.To, toErr = time.Parse(DateLayout, to); toErr != nil {
+			v.Check(validation.NewFieldError("to", validation.Format, "invalid to, it must be YYYY-MM-DD"))
+		}
+	}
+	params.From = params.To.AddDate(0, 0, -defaultHistoryDays)
+	if from := q.Get("from"); from != "" {
+		if params.From, fromErr = time.Parse(DateLayout, from); fromErr != nil {
+			v.Check(validation.NewFieldError("from", validation.Format, "invalid from, it must be YYYY-MM-DD"))
+		}
+	}
+	if toErr == nil && fromErr == nil {
+		if params.From.After(params.To) {
+			v.Check(validation.NewFieldError("from", validation.Range, "from cannot be after to"))
+		} else if params.To.Sub(params.From) > maxHistoryDays*24*time.Hour {
+			v.Check(validation.NewFieldError("from", validation.Range, "the range cannot be longer than 366 days"))
+		}
+	}
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+	return &params, nil
+}
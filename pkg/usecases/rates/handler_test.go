@@ -0,0 +1,124 @@
+package rates_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
+	"github.com/rgraterol/beers-api/pkg/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListRatesOk200(t *testing.T) {
+	//GIVEN
+	history := &historyMock{}
+	handler := rates.List(history)
+	req := httptest.NewRequest(http.MethodGet, "/rates?currency=CLP&from=2022-01-01&to=2022-02-06", nil)
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp rates.History
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "CLP", history.params.Currency)
+	assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), history.params.From)
+	assert.Equal(t, time.Date(2022, 2, 6, 0, 0, 0, 0, time.UTC), history.params.To)
+	assert.Len(t, resp.Results, 1)
+}
+
+func TestListRatesDefaultRange(t *testing.T) {
+	//GIVEN
+	history := &historyMock{}
+	handler := rates.List(history)
+	req := httptest.NewRequest(http.MethodGet, "/rates?currency=CLP", nil)
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	//THEN
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, rates.Day(time.Now()), history.params.To)
+	assert.Equal(t, history.params.To.AddDate(0, 0, -30), history.params.From)
+}
+
+func TestListRatesInvalidParams400(t *testing.T) {
+	cases := map[string]string{
+		"/rates":                                            "currency cannot be empty",
+		"/rates?currency=CL":                                "invalid currency",
+		"/rates?currency=XYZ":                               "invalid currency",
+		"/rates?currency=CLP&from=01-01-2022":               "invalid from, it must be YYYY-MM-DD",
+		"/rates?currency=CLP&to=tomorrow":                   "invalid to, it must be YYYY-MM-DD",
+		"/rates?currency=CLP&from=2022-02-06&to=2022-02-05": "from cannot be after to",
+		"/rates?currency=CLP&from=2020-01-01&to=2022-02-05": "the range cannot be longer than 366 days",
+	}
+	for url, message := range cases {
+		//GIVEN
+		handler := rates.List(&historyMock{})
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		w := httptest.NewRecorder()
+		//WHEN
+		handler(w, req)
+		res := w.Result()
+		var resp map[string]interface{}
+		err := json.NewDecoder(res.Body).Decode(&resp)
+		//THEN
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode, url)
+		assert.Equal(t, message, resp["message"], url)
+	}
+}
+
+func TestListRatesNormalisesTheCurrency(t *testing.T) {
+	//GIVEN
+	history := &historyMock{}
+	handler := rates.List(history)
+	req := httptest.NewRequest(http.MethodGet, "/rates?currency=usd", nil)
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	//THEN
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	assert.Equal(t, "USD", history.params.Currency)
+}
+
+func TestListRatesEveryInvalidParam400(t *testing.T) {
+	//GIVEN
+	handler := rates.List(&historyMock{})
+	req := httptest.NewRequest(http.MethodGet, "/rates?currency=dollars&from=yesterday&to=2022-02-05", nil)
+	w := httptest.NewRecorder()
+	//WHEN
+	handler(w, req)
+	res := w.Result()
+	var resp struct {
+		Cause []validation.FieldError `json:"cause"`
+	}
+	err := json.NewDecoder(res.Body).Decode(&resp)
+	//THEN
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Equal(t, []validation.FieldError{
+		{Field: "currency", Rule: validation.ISO4217, Message: "invalid currency"},
+		{Field: "from", Rule: validation.Format, Message: "invalid from, it must be YYYY-MM-DD"},
+	}, resp.Cause)
+}
+
+type historyMock struct {
+	params *rates.HistoryParameters
+}
+
+func (h *historyMock) History(_ context.Context, params *rates.HistoryParameters) (*rates.History, error) {
+	h.params = params
+	return &rates.History{
+		Currency: params.Currency,
+		From:     params.From.Format(rates.DateLayout),
+		To:       params.To.Format(rates.DateLayout),
+		Results:  []rates.RatePoint{{Base: "USD", Rate: money.RequireFromString("828.503912"), Source: "currencylayer"}},
+	}, nil
+}
@@ -0,0 +1,63 @@
+package rates
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type Interface interface {
+	GetRates(ctx context.Context) (*Rates, error)
+}
+
+// ProviderFunc adapts a function to an Interface.
+type ProviderFunc func(ctx context.Context) (*Rates, error)
+
+func (f ProviderFunc) GetRates(ctx context.Context) (*Rates, error) {
+	return f(ctx)
+}
+
+// StatsInterface is implemented by the providers keeping their rates in a Cache.
+type StatsInterface interface {
+	Stats() CacheStats
+}
+
+// HistoricalInterface is implemented by the providers that know the rates of past days.
+type HistoricalInterface interface {
+	GetHistoricalRates(ctx context.Context, date time.Time) (*Rates, error)
+}
+
+// HistoryInterface serves the saved rates over time.
+type HistoryInterface interface {
+	History(ctx context.Context, params *HistoryParameters) (*History, error)
+}
+
+// HistoricalUnsupportedError is answered when a provider only knows the current rates.
+var HistoricalUnsupportedError error = &unsupportedError{"historical exchange rates are not supported by the provider"}
+
+type unsupportedError struct {
+	message string
+}
+
+func (e *unsupportedError) Error() string {
+	return e.message
+}
+
+func (e *unsupportedError) StatusCode() int {
+	return http.StatusNotImplemented
+}
+
+// HistoricalRates asks p for the rates of the UTC day of date.
+func HistoricalRates(ctx context.Context, p Interface, date time.Time) (*Rates, error) {
+	historical, ok := p.(HistoricalInterface)
+	if !ok {
+		return nil, HistoricalUnsupportedError
+	}
+	return historical.GetHistoricalRates(ctx, Day(date))
+}
+
+// Day truncates t to the start of its UTC day.
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
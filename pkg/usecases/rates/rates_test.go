@@ -0,0 +1,101 @@
+package rates_test
+
+import (
+	"context"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/restclient"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
+	"github.com/stretchr/testify/assert"
+)
+
+const ecbMock = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time='2022-02-04'>
+			<Cube currency='USD' rate='1.1448'/>
+			<Cube currency='GBP' rate='0.84560'/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+const staticMock = `base: USD
+timestamp: 2022-02-06T08:11:05Z
+rates:
+  CLP: 828.503912
+  ARS: 105.356594
+`
+
+func TestRateOfBaseCurrency(t *testing.T) {
+	r := rates.Rates{Base: "EUR", Rates: map[string]money.Decimal{"USD": money.RequireFromString("1.1448")}}
+	assert.Equal(t, "1", r.Rate("EUR").String())
+	assert.Equal(t, "1.1448", r.Rate("USD").String())
+	assert.Equal(t, "0", r.Rate("CLP").String())
+}
+
+func TestECBProviderOk(t *testing.T) {
+	// Given
+	client := restclient.NewMock(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, rates.ECBDailyURL, req.URL.String())
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte(ecbMock))),
+		}, nil
+	})
+	p := rates.ECBProvider{Client: client}
+	// When
+	r, err := p.GetRates(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "EUR", r.Base)
+	assert.Equal(t, "ecb", r.Source)
+	assert.Equal(t, time.Date(2022, 2, 4, 0, 0, 0, 0, time.UTC), r.Timestamp)
+	assert.Equal(t, "0.8456", r.Rate("GBP").String())
+}
+
+func TestECBProviderStatusError(t *testing.T) {
+	// Given
+	client := restclient.NewMock(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		}, nil
+	})
+	p := rates.ECBProvider{URL: "http://ecb.local/daily.xml", Client: client}
+	// When
+	r, err := p.GetRates(context.Background())
+	// Then
+	assert.Nil(t, r)
+	assert.Contains(t, err.Error(), "status 503")
+}
+
+func TestStaticProviderOk(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "rates.yml")
+	assert.Nil(t, os.WriteFile(path, []byte(staticMock), 0644))
+	p, err := rates.NewStaticProvider(path)
+	assert.Nil(t, err)
+	// When
+	r, err := p.GetRates(context.Background())
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "USD", r.Base)
+	assert.Equal(t, "static", r.Source)
+	assert.Equal(t, "828.503912", r.Rate("CLP").String())
+}
+
+func TestStaticProviderMissingFile(t *testing.T) {
+	// When
+	p, err := rates.NewStaticProvider(filepath.Join(t.TempDir(), "missing.yml"))
+	// Then
+	assert.Nil(t, p)
+	assert.Contains(t, err.Error(), "cannot read the rates file")
+}
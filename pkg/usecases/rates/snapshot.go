@@ -0,0 +1,71 @@
+package rates
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/tracing"
+)
+
+// Snapshotter saves the rates of Provider on the Recorder every Interval, keeping the history of the rates
+// even when no box price asks for them.
+type Snapshotter struct {
+	Provider Interface
+	Recorder Recorder
+	Interval time.Duration
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func NewSnapshotter(provider Interface, recorder Recorder, interval time.Duration) *Snapshotter {
+	return &Snapshotter{Provider: provider, Recorder: recorder, Interval: interval}
+}
+
+// Snapshot saves the current rates of the provider once.
+func (s *Snapshotter) Snapshot(ctx context.Context) error {
+	r, err := s.Provider.GetRates(ctx)
+	if err != nil {
+		return err
+	}
+	return s.Recorder.Save(ctx, r)
+}
+
+// Start takes a snapshot right away and then one every Interval, until Stop is called. The snapshots run on ctx, so
+// they log and trace with its logger and tracing provider.
+func (s *Snapshotter) Start(ctx context.Context) {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	ctx, cancel := context.WithCancel(ctx)
+	s.cancel = cancel
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+		for {
+			if err := s.Snapshot(ctx); err != nil {
+				tracing.Logger(ctx).Error("cannot snapshot the exchange rates ", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop cancels the running snapshot and waits for it to return.
+func (s *Snapshotter) Stop() {
+	if s.stop == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		s.cancel()
+	})
+	<-s.done
+}
@@ -0,0 +1,121 @@
+package rates_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rgraterol/beers-api/pkg/money"
+	"github.com/rgraterol/beers-api/pkg/usecases/rates"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotterSavesProviderRates(t *testing.T) {
+	// Given
+	store := rates.NewDBStore(initRatesTestDB(t))
+	provider := &providerMock{rates: ratesMock(time.Date(2022, 2, 6, 8, 0, 0, 0, time.UTC))}
+	s := rates.NewSnapshotter(provider, store, time.Hour)
+	// When
+	err := s.Snapshot(context.Background())
+	// Then
+	assert.Nil(t, err)
+	history, err := store.History(context.Background(), &rates.HistoryParameters{
+		Currency: "CLP",
+		From:     time.Date(2022, 2, 6, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2022, 2, 6, 0, 0, 0, 0, time.UTC),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []rates.RatePoint{{
+		Base:    "USD",
+		Rate:    money.RequireFromString("828.503912"),
+		RatedAt: time.Date(2022, 2, 6, 8, 0, 0, 0, time.UTC),
+		Source:  "currencylayer",
+	}}, history.Results)
+}
+
+func TestSnapshotterStartAndStop(t *testing.T) {
+	// Given
+	recorder := &recorderMock{}
+	s := rates.NewSnapshotter(&providerMock{}, recorder, time.Millisecond)
+	// When
+	s.Start(context.Background())
+	assert.Eventually(t, func() bool { return recorder.count() >= 2 }, time.Second, time.Millisecond)
+	s.Stop()
+	// Then
+	saved := recorder.count()
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, saved, recorder.count())
+}
+
+func TestSnapshotterStopCancelsTheRunningSnapshot(t *testing.T) {
+	// Given
+	recorder := &recorderMock{}
+	s := rates.NewSnapshotter(&stalledProviderMock{}, recorder, time.Hour)
+	s.Start(context.Background())
+	stopped := make(chan struct{})
+	// When
+	go func() {
+		s.Stop()
+		close(stopped)
+	}()
+	// Then
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop waited for the stalled snapshot")
+	}
+	assert.Equal(t, 0, recorder.count())
+}
+
+func TestSnapshotterProviderError(t *testing.T) {
+	// Given
+	recorder := &recorderMock{}
+	s := rates.NewSnapshotter(&providerMock{err: errors.New("quota exceeded")}, recorder, time.Hour)
+	// When
+	err := s.Snapshot(context.Background())
+	// Then
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, recorder.count())
+}
+
+func TestHistoryBetweenDays(t *testing.T) {
+	// Given
+	store := rates.NewDBStore(initRatesTestDB(t))
+	assert.Nil(t, store.Save(context.Background(), ratesMock(time.Date(2022, 2, 4, 23, 0, 0, 0, time.UTC))))
+	assert.Nil(t, store.Save(context.Background(), ratesMock(time.Date(2022, 2, 5, 8, 0, 0, 0, time.UTC))))
+	assert.Nil(t, store.Save(context.Background(), ratesMock(time.Date(2022, 2, 6, 23, 59, 0, 0, time.UTC))))
+	assert.Nil(t, store.Save(context.Background(), ratesMock(time.Date(2022, 2, 7, 0, 0, 0, 0, time.UTC))))
+	// When
+	history, err := store.History(context.Background(), &rates.HistoryParameters{
+		Currency: "ARS",
+		From:     time.Date(2022, 2, 5, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2022, 2, 6, 0, 0, 0, 0, time.UTC),
+	})
+	// Then
+	assert.Nil(t, err)
+	assert.Equal(t, "2022-02-05", history.From)
+	assert.Equal(t, "2022-02-06", history.To)
+	assert.Len(t, history.Results, 2)
+	assert.Equal(t, time.Date(2022, 2, 5, 8, 0, 0, 0, time.UTC), history.Results[0].RatedAt)
+	assert.Equal(t, time.Date(2022, 2, 6, 23, 59, 0, 0, time.UTC), history.Results[1].RatedAt)
+}
+
+type recorderMock struct {
+	mu    sync.Mutex
+	saved int
+}
+
+func (r *recorderMock) Save(context.Context, *rates.Rates) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.saved++
+	return nil
+}
+
+func (r *recorderMock) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.saved
+}
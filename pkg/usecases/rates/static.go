@@ -0,0 +1,49 @@
+package rates
+
+import (
+	"context"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/pkg/money"
+)
+
+const staticSource = "static"
+
+// StaticProvider serves fixed rates read once from a YAML file, handy for offline environments and tests.
+type StaticProvider struct {
+	rates Rates
+}
+
+// NewStaticProvider loads a rates file such as:
+//
+//	base: USD
+//	timestamp: 2022-02-06T08:11:05Z
+//	rates:
+//	  CLP: 828.503912
+//	  ARS: 105.356594
+func NewStaticProvider(path string) (*StaticProvider, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read the rates file "+path)
+	}
+	var p StaticProvider
+	if err = yaml.Unmarshal(content, &p.rates); err != nil {
+		return nil, errors.Wrap(err, "cannot decode the rates file "+path)
+	}
+	if p.rates.Base == "" || len(p.rates.Rates) == 0 {
+		return nil, errors.New("the rates file " + path + " needs a base and its rates")
+	}
+	p.rates.Source = staticSource
+	return &p, nil
+}
+
+func (p *StaticProvider) GetRates(context.Context) (*Rates, error) {
+	r := p.rates
+	r.Rates = make(map[string]money.Decimal, len(p.rates.Rates))
+	for currency, rate := range p.rates.Rates {
+		r.Rates[currency] = rate
+	}
+	return &r, nil
+}
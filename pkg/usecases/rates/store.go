@@ -0,0 +1,132 @@
+package rates
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rgraterol/beers-api/pkg/money"
+)
+
+const databaseSource = "database"
+
+// ExchangeRate is a single rate of a snapshot taken from a provider.
+type ExchangeRate struct {
+	ID        int64         `gorm:"primaryKey"`
+	Base      string        `gorm:"size:3;uniqueIndex:idx_exchange_rates_snapshot,priority:2"`
+	Currency  string        `gorm:"size:3;uniqueIndex:idx_exchange_rates_snapshot,priority:3;index:idx_exchange_rates_currency,priority:1"`
+	Rate      money.Decimal `gorm:"type:decimal(24,12);not null"`
+	RatedAt   time.Time     `gorm:"uniqueIndex:idx_exchange_rates_snapshot,priority:1;index:idx_exchange_rates_currency,priority:2"`
+	Source    string        `gorm:"size:20"`
+	CreatedAt time.Time
+}
+
+// DBStore persists the rates served by the primary provider and serves back the latest snapshot, keeping its
+// original timestamp so clients know how old it is.
+type DBStore struct {
+	DB *gorm.DB
+
+	mu        sync.Mutex
+	lastSaved time.Time
+}
+
+func NewDBStore(g *gorm.DB) *DBStore {
+	return &DBStore{DB: g}
+}
+
+// Save keeps the snapshot r once, the rates another instance already saved for the same time are left as they are.
+func (s *DBStore) Save(ctx context.Context, r *Rates) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !r.Timestamp.After(s.lastSaved) {
+		return nil
+	}
+	snapshot := make([]ExchangeRate, 0, len(r.Rates))
+	for currency, rate := range r.Rates {
+		snapshot = append(snapshot, ExchangeRate{
+			Base:     r.Base,
+			Currency: currency,
+			Rate:     rate,
+			RatedAt:  r.Timestamp,
+			Source:   r.Source,
+		})
+	}
+	trx := s.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(snapshot, 100)
+	if trx.Error != nil {
+		return errors.Wrap(trx.Error, "cannot save the exchange rates")
+	}
+	s.lastSaved = r.Timestamp
+	return nil
+}
+
+func (s *DBStore) GetRates(ctx context.Context) (*Rates, error) {
+	var latest ExchangeRate
+	trx := s.DB.WithContext(ctx).Order("rated_at desc").First(&latest)
+	if trx.Error != nil {
+		return nil, errors.Wrap(trx.Error, "cannot find saved exchange rates")
+	}
+	return s.loadSnapshot(ctx, &latest)
+}
+
+// GetHistoricalRates serves the last snapshot saved during the day of date.
+func (s *DBStore) GetHistoricalRates(ctx context.Context, date time.Time) (*Rates, error) {
+	day := Day(date)
+	var latest ExchangeRate
+	trx := s.DB.WithContext(ctx).Where("rated_at >= ? AND rated_at < ?", day, day.AddDate(0, 0, 1)).
+		Order("rated_at desc").
+		First(&latest)
+	if trx.Error != nil {
+		return nil, errors.Wrap(trx.Error, "cannot find saved exchange rates for "+day.Format(DateLayout))
+	}
+	return s.loadSnapshot(ctx, &latest)
+}
+
+// History lists the saved rates of a currency, the range is not bounded here so callers must limit it.
+func (s *DBStore) History(ctx context.Context, params *HistoryParameters) (*History, error) {
+	from, to := Day(params.From), Day(params.To)
+	var saved []ExchangeRate
+	trx := s.DB.WithContext(ctx).Where("currency = ? AND rated_at >= ? AND rated_at < ?", params.Currency, from, to.AddDate(0, 0, 1)).
+		Order("rated_at asc").
+		Order("id asc").
+		Find(&saved)
+	if trx.Error != nil {
+		return nil, errors.Wrap(trx.Error, "cannot find saved exchange rates")
+	}
+	h := History{
+		Currency: params.Currency,
+		From:     from.Format(DateLayout),
+		To:       to.Format(DateLayout),
+		Results:  make([]RatePoint, 0, len(saved)),
+	}
+	for _, rate := range saved {
+		h.Results = append(h.Results, RatePoint{
+			Base:    rate.Base,
+			Rate:    rate.Rate,
+			RatedAt: rate.RatedAt.UTC(),
+			Source:  rate.Source,
+		})
+	}
+	return &h, nil
+}
+
+// loadSnapshot reads every rate saved along with latest.
+func (s *DBStore) loadSnapshot(ctx context.Context, latest *ExchangeRate) (*Rates, error) {
+	var snapshot []ExchangeRate
+	trx := s.DB.WithContext(ctx).Where("rated_at = ? AND base = ?", latest.RatedAt, latest.Base).Find(&snapshot)
+	if trx.Error != nil {
+		return nil, errors.Wrap(trx.Error, "cannot find saved exchange rates")
+	}
+	r := Rates{
+		Base:      latest.Base,
+		Timestamp: latest.RatedAt.UTC(),
+		Source:    databaseSource,
+		Rates:     make(map[string]money.Decimal, len(snapshot)),
+	}
+	for _, rate := range snapshot {
+		r.Rates[rate.Currency] = rate.Rate
+	}
+	return &r, nil
+}
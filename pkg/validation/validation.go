@@ -0,0 +1,92 @@
+package validation
+
+import "net/http"
+
+// Rules broken by a field.
+const (
+	Required = "required"
+	Format   = "format"
+	Length   = "length"
+	Range    = "range"
+	ISO4217  = "iso4217"
+	ISO3166  = "iso3166"
+	// Unsupported is broken by the params an endpoint does not take.
+	Unsupported = "unsupported"
+)
+
+// FieldError is a rule broken by a field of a request, it is answered as a 400 with itself as the only cause.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func NewFieldError(field string, rule string, message string) FieldError {
+	return FieldError{Field: field, Rule: rule, Message: message}
+}
+
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+func (e FieldError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+func (e FieldError) Causes() []FieldError {
+	return []FieldError{e}
+}
+
+// Errors collects every field error of a request, keeping the first rule broken by each field. Its message is the
+// one of the first error, the rest are listed by Causes.
+type Errors struct {
+	Fields []FieldError
+}
+
+// Check adds err when it is not nil, errors other than FieldError are added without field nor rule.
+func (e *Errors) Check(err error) {
+	if err == nil {
+		return
+	}
+	fe, ok := err.(FieldError)
+	if !ok {
+		fe = FieldError{Message: err.Error()}
+	}
+	if fe.Field != "" && e.Has(fe.Field) {
+		return
+	}
+	e.Fields = append(e.Fields, fe)
+}
+
+// Has tells if field already broke a rule.
+func (e *Errors) Has(field string) bool {
+	for _, fe := range e.Fields {
+		if fe.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Err answers the collected errors, nil when every field is valid.
+func (e *Errors) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *Errors) Error() string {
+	if len(e.Fields) == 0 {
+		return ""
+	}
+	return e.Fields[0].Message
+}
+
+func (e *Errors) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+func (e *Errors) Causes() []FieldError {
+	return e.Fields
+}
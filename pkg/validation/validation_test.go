@@ -0,0 +1,49 @@
+package validation_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/rgraterol/beers-api/pkg/validation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorsEmpty(t *testing.T) {
+	// Given
+	var v validation.Errors
+	// When
+	v.Check(nil)
+	// Then
+	assert.Nil(t, v.Err())
+}
+
+func TestErrorsKeepFirstRuleOfEachField(t *testing.T) {
+	// Given
+	var v validation.Errors
+	// When
+	v.Check(validation.NewFieldError("price", validation.Format, "invalid price"))
+	v.Check(validation.NewFieldError("price", validation.Required, "price cannot be zero nor empty"))
+	v.Check(validation.NewFieldError("name", validation.Required, "name cannot be empty"))
+	v.Check(errors.New("unexpected"))
+	err := v.Err()
+	// Then
+	assert.NotNil(t, err)
+	assert.Equal(t, "invalid price", err.Error())
+	assert.Equal(t, http.StatusBadRequest, v.StatusCode())
+	assert.Equal(t, []validation.FieldError{
+		{Field: "price", Rule: validation.Format, Message: "invalid price"},
+		{Field: "name", Rule: validation.Required, Message: "name cannot be empty"},
+		{Message: "unexpected"},
+	}, v.Causes())
+}
+
+func TestFieldErrorIsItsOwnCause(t *testing.T) {
+	// Given
+	fe := validation.NewFieldError("date", validation.Range, "date cannot be in the future")
+	// When
+	causes := fe.Causes()
+	// Then
+	assert.Equal(t, http.StatusBadRequest, fe.StatusCode())
+	assert.Equal(t, []validation.FieldError{fe}, causes)
+}